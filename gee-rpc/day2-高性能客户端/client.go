@@ -1,13 +1,13 @@
 package geerpc
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"geerpc/codec"
 	"io"
 	"log"
 	"net"
+	"reflect"
 	"sync"
 )
 
@@ -37,6 +37,7 @@ type Client struct {
 	pending  map[uint64]*Call
 	closing  bool // user has called Close
 	shutdown bool // server has told us to stop
+	notifies map[string]reflect.Value // handlers registered by OnNotify
 }
 
 var _ io.Closer = (*Client)(nil)
@@ -123,6 +124,61 @@ func (client *Client) send(call *Call) {
 	}
 }
 
+// OnNotify registers handler for notifications pushed by the server
+// with the given method. handler must be a func with exactly one argument,
+// the notification body is decoded into it. Handlers are invoked in order
+// on the receiving goroutine, so they should not block.
+func (client *Client) OnNotify(method string, handler interface{}) error {
+	fn := reflect.ValueOf(handler)
+	if fn.Kind() != reflect.Func || fn.Type().NumIn() != 1 || fn.Type().NumOut() != 0 {
+		return fmt.Errorf("rpc client: notify handler for %s must be func(T)", method)
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.notifies == nil {
+		client.notifies = make(map[string]reflect.Value)
+	}
+	client.notifies[method] = fn
+	return nil
+}
+
+// handleNotify decodes the body of a notification and dispatches it.
+// Notifications without a registered handler are discarded.
+func (client *Client) handleNotify(h *codec.Header) error {
+	client.mu.Lock()
+	fn, ok := client.notifies[h.ServiceMethod]
+	client.mu.Unlock()
+	if !ok {
+		return client.cc.ReadBody(nil)
+	}
+	argType := fn.Type().In(0)
+	isPtr := argType.Kind() == reflect.Ptr
+	if isPtr {
+		argType = argType.Elem()
+	}
+	argv := reflect.New(argType)
+	if err := client.cc.ReadBody(argv.Interface()); err != nil {
+		return err
+	}
+	if !isPtr {
+		argv = argv.Elem()
+	}
+	callNotify(h.ServiceMethod, fn, argv)
+	return nil
+}
+
+// callNotify invokes a notify handler. A panic in the handler is logged
+// and swallowed, otherwise it would kill the receiving goroutine and
+// leave every pending call hanging.
+func callNotify(method string, fn, argv reflect.Value) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("rpc client: notify handler for %s panicked: %v", method, r)
+		}
+	}()
+	fn.Call([]reflect.Value{argv})
+}
+
 func (client *Client) receive() {
 	var err error
 	for err == nil {
@@ -130,6 +186,11 @@ func (client *Client) receive() {
 		if err = client.cc.ReadHeader(&h); err != nil {
 			break
 		}
+		if h.Notify {
+			// one-way message from server, never matches a pending call
+			err = client.handleNotify(&h)
+			continue
+		}
 		call := client.removeCall(h.Seq)
 		switch {
 		case call == nil:
@@ -185,12 +246,13 @@ func parseOptions(opts ...*Option) (*Option, error) {
 	if len(opts) != 1 {
 		return nil, errors.New("number of options is more than 1")
 	}
-	opt := opts[0]
+	// copy the option, it may be shared by concurrent Dial calls
+	opt := *opts[0]
 	opt.MagicNumber = DefaultOption.MagicNumber
 	if opt.CodecType == "" {
 		opt.CodecType = DefaultOption.CodecType
 	}
-	return opt, nil
+	return &opt, nil
 }
 
 func NewClient(conn net.Conn, opt *Option) (*Client, error) {
@@ -201,7 +263,7 @@ func NewClient(conn net.Conn, opt *Option) (*Client, error) {
 		return nil, err
 	}
 	// send options with server
-	if err := json.NewEncoder(conn).Encode(opt); err != nil {
+	if err := writeOption(conn, opt); err != nil {
 		log.Println("rpc client: options error: ", err)
 		_ = conn.Close()
 		return nil, err
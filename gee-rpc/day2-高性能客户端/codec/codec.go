@@ -10,6 +10,7 @@ type Header struct {
 	ServiceMethod string // 格式为 "Service.Method"
 	Seq           uint64 // 客户端选择的序列号
 	Error         string
+	Notify        bool // 服务端主动推送的单向通知，此时 Seq 恒为 0，客户端无需回复
 }
 
 // 抽象出对消息体进行编解码的接口 Codec，抽象出接口是为了实现不同的 Codec 实例
@@ -0,0 +1,150 @@
+// 录制连接上收发的每一对 header/body，供测试回放
+package codec
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"io"
+	"log"
+	"reflect"
+	"sync"
+)
+
+// Record 是录制文件中的一行（JSON），对应一次完整的读或写
+type Record struct {
+	Dir    string // "read" 或 "write"，相对于录制的一方
+	Header Header
+	Body   json.RawMessage // body 的 JSON 编码，丢弃的 body 为 null，回放时用于匹配请求参数
+	// Gob 是 body 以 interface 形式的 gob 编码，带有具体类型名，回放时据此还原出原类型的值，
+	// 避免 JSON 把整数变为 float64、把结构体变为 map；丢弃的 body 为空
+	Gob []byte `json:",omitempty"`
+}
+
+// RecordingCodec 包装一个 Codec，把经过它的每一对 header/body 以 JSON 行的形式写入 w
+type RecordingCodec struct {
+	Codec
+	mu     sync.Mutex // protect following
+	enc    *json.Encoder
+	header Header // 最近一次读到的 header，等待与 body 一起记录
+}
+
+var _ Codec = (*RecordingCodec)(nil)
+
+// NewRecordingCodec 返回包装了 cc 的 RecordingCodec，录制结果写入 w
+// 一个 w 只应录制一个连接，回放时按 Seq 配对请求与响应。
+// 可以通过替换 NewCodecFuncMap 中的构造函数，为每个新连接创建录制文件
+func NewRecordingCodec(cc Codec, w io.Writer) Codec {
+	return &RecordingCodec{Codec: cc, enc: json.NewEncoder(w)}
+}
+
+func (c *RecordingCodec) ReadHeader(h *Header) error {
+	if err := c.Codec.ReadHeader(h); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.header = *h
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *RecordingCodec) ReadBody(body interface{}) error {
+	if err := c.Codec.ReadBody(body); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.record("read", &c.header, body)
+	return nil
+}
+
+func (c *RecordingCodec) Write(h *Header, body interface{}) error {
+	if err := c.Codec.Write(h, body); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.record("write", h, body)
+	return nil
+}
+
+// record 写入一条记录，调用时需持有 c.mu
+// 录制失败不影响连接本身，只记录日志
+func (c *RecordingCodec) record(dir string, h *Header, body interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		log.Println("rpc codec: record body error:", err)
+		data = []byte("null")
+	}
+	typed, err := gobBody(body)
+	if err != nil {
+		log.Println("rpc codec: record body error:", err)
+	}
+	if err := c.enc.Encode(&Record{Dir: dir, Header: *h, Body: data, Gob: typed}); err != nil {
+		log.Println("rpc codec: write record error:", err)
+	}
+}
+
+// gobBody 把 body 指向的值以 interface 的形式做 gob 编码，body 为 nil 时返回 nil
+// 编码 interface 需要先用 gob.Register 注册具体类型，尚未注册的类型在这里自动注册
+func gobBody(body interface{}) ([]byte, error) {
+	v := reflect.ValueOf(body)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, nil
+	}
+	value := v.Interface()
+	if _, ok := registered.Load(v.Type()); ok {
+		return encodeInterface(value)
+	}
+	// 基本类型以及调用者已经注册过（可能用的是 gob.RegisterName 的其他名字）的类型可以直接编码，不再注册
+	if data, err := encodeInterface(value); err == nil {
+		registered.Store(v.Type(), struct{}{})
+		return data, nil
+	}
+	// 到这里类型尚未注册，gob.Register 只会在默认名字已被另一个类型占用时 panic，这属于程序的错误，不做处理
+	gob.Register(value)
+	registered.Store(v.Type(), struct{}{})
+	return encodeInterface(value)
+}
+
+// registered 记录 gobBody 已经确认可以编码的类型，每个类型只注册一次
+var registered sync.Map // reflect.Type -> struct{}
+
+func encodeInterface(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeBody 把 Record.Gob 还原为录制时的值，类型与录制时相同
+// 基本类型以外的类型需要在当前进程中用 gob.Register 注册（与录制在同一进程时已自动注册）
+func (r *Record) DecodeBody() (interface{}, error) {
+	var value interface{}
+	if err := gob.NewDecoder(bytes.NewReader(r.Gob)).Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// ReadRecords 读取 RecordingCodec 写出的全部记录
+func ReadRecords(r io.Reader) ([]Record, error) {
+	dec := json.NewDecoder(r)
+	var records []Record
+	for {
+		var rec Record
+		if err := dec.Decode(&rec); err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+}
@@ -0,0 +1,81 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/gob"
+	"net"
+	"testing"
+)
+
+type point struct {
+	X, Y int
+}
+
+func TestRecordingCodec(t *testing.T) {
+	client, server := net.Pipe()
+	defer func() { _ = client.Close() }()
+
+	var buf bytes.Buffer
+	rc := NewRecordingCodec(NewGobCodec(server), &buf)
+	peer := NewGobCodec(client)
+
+	go func() {
+		_ = peer.Write(&Header{ServiceMethod: "Geo.Move", Seq: 1}, "north")
+	}()
+	var h Header
+	var arg string
+	if err := rc.ReadHeader(&h); err != nil {
+		t.Fatal(err)
+	}
+	if err := rc.ReadBody(&arg); err != nil || arg != "north" {
+		t.Fatalf("read body: %q, %v", arg, err)
+	}
+	go func() {
+		var h Header
+		var p point
+		_ = peer.ReadHeader(&h)
+		_ = peer.ReadBody(&p)
+	}()
+	if err := rc.Write(&h, &point{X: 1, Y: 2}); err != nil {
+		t.Fatal(err)
+	}
+	_ = rc.Close()
+
+	records, err := ReadRecords(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Dir != "read" || records[1].Dir != "write" {
+		t.Fatalf("unexpected records %+v", records)
+	}
+	if string(records[0].Body) != `"north"` || records[1].Header.Seq != 1 {
+		t.Fatalf("unexpected records %+v", records)
+	}
+	// gob 形式的 body 保留了具体类型
+	body, err := records[1].DecodeBody()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := body.(point); !ok || p != (point{X: 1, Y: 2}) {
+		t.Fatalf("expect point{1 2}, got %#v", body)
+	}
+}
+
+type renamed struct {
+	Name string
+}
+
+func TestGobBodyRegisteredName(t *testing.T) {
+	// 调用者用其他名字注册过的类型沿用已有的注册，不会因为重复注册而 panic
+	gob.RegisterName("codec.Renamed", renamed{})
+	for i := 0; i < 2; i++ {
+		data, err := gobBody(&renamed{Name: "a"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := (&Record{Gob: data}).DecodeBody()
+		if err != nil || body != (renamed{Name: "a"}) {
+			t.Fatalf("expect renamed{a}, got %#v, %v", body, err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package consistenthash
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestHashing(t *testing.T) {
+	hash := New(3, func(key []byte) uint32 {
+		i, _ := strconv.Atoi(string(key))
+		return uint32(i)
+	})
+
+	// Given the above hash function, this will give replicas with "hashes":
+	// 2, 4, 6, 12, 14, 16, 22, 24, 26
+	hash.Add("6", "4", "2")
+
+	testCases := map[string]string{
+		"2":  "2",
+		"11": "2",
+		"23": "4",
+		"27": "2",
+	}
+
+	for k, v := range testCases {
+		if hash.Get(k) != v {
+			t.Errorf("Asking for %s, should have yielded %s", k, v)
+		}
+	}
+
+	// Adds 8, 18, 28
+	hash.Add("8")
+
+	// 27 should now map to 8.
+	testCases["27"] = "8"
+
+	for k, v := range testCases {
+		if hash.Get(k) != v {
+			t.Errorf("Asking for %s, should have yielded %s", k, v)
+		}
+	}
+
+}
+
+func TestMinimalRemapping(t *testing.T) {
+	before := New(50, nil)
+	before.Add("10.0.0.1:9999", "10.0.0.2:9999", "10.0.0.3:9999")
+	after := New(50, nil)
+	after.Add("10.0.0.1:9999", "10.0.0.2:9999", "10.0.0.3:9999", "10.0.0.4:9999")
+
+	// 新增节点后，只有落到新节点上的 key 会改变归属
+	for i := 0; i < 1000; i++ {
+		key := "user-" + strconv.Itoa(i)
+		if from, to := before.Get(key), after.Get(key); from != to && to != "10.0.0.4:9999" {
+			t.Fatalf("key %s moved from %s to %s", key, from, to)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package geerpc
+
+// 健康检查服务的状态，Health.Check 的回复为其中之一
+const (
+	Serving    = "SERVING"
+	NotServing = "NOT_SERVING"
+)
+
+// HealthCheckMethod 是内置健康检查服务的方法名，注册在每一个 Server 上
+const HealthCheckMethod = "Health.Check"
+
+// SetServingStatus 切换健康检查的状态
+// 关闭服务前先置为 NOT_SERVING，客户端的健康检查会在连接断开前将其摘除
+func (server *Server) SetServingStatus(serving bool) {
+	server.notServing.Store(!serving)
+}
+
+// ServingStatus 返回当前的健康检查状态
+func (server *Server) ServingStatus() string {
+	if server.notServing.Load() {
+		return NotServing
+	}
+	return Serving
+}
+
+// SetServingStatus 切换 DefaultServer 的健康检查状态
+func SetServingStatus(serving bool) { DefaultServer.SetServingStatus(serving) }
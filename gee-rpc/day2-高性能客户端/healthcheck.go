@@ -0,0 +1,217 @@
+package geerpc
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// HealthOption 配置客户端的健康检查与异常节点摘除
+// 为零（或负数）的字段使用 DefaultHealthOption 中的值
+type HealthOption struct {
+	Interval      time.Duration // 主动探测的间隔
+	Timeout       time.Duration // 单次探测（建立连接 + Health.Check）的超时时间
+	MaxFailures   int           // 连续失败（探测或调用）多少次后摘除该节点
+	EjectDuration time.Duration // 摘除时长，到期后重新接收流量
+	// Option 是探测连接使用的 Option（如编解码方式），为 nil 时使用 DefaultOption
+	// 与 XClient 一起使用时应传入与 XClient 相同的 Option
+	Option *Option
+}
+
+var DefaultHealthOption = &HealthOption{
+	Interval:      10 * time.Second,
+	Timeout:       2 * time.Second,
+	MaxFailures:   3,
+	EjectDuration: 30 * time.Second,
+}
+
+// HealthChecker 周期性地对一组服务端地址调用 Health.Check，
+// 并结合调用方上报的调用结果，暂时摘除连续失败的节点
+type HealthChecker struct {
+	opt *HealthOption
+
+	mu      sync.Mutex // protect following
+	addrs   []string
+	servers map[string]*serverHealth
+	closed  bool
+	done    chan struct{}
+}
+
+// serverHealth 记录单个节点的健康状态
+type serverHealth struct {
+	failures     int       // 连续失败次数
+	ejectedUntil time.Time // 非零表示被摘除，直到该时间为止
+}
+
+var ErrHealthCheckTimeout = errors.New("rpc health: check timeout")
+
+// withDefaults 返回 opt 的副本，opt 为 nil 或字段非正时使用 DefaultHealthOption 中的值
+func (opt *HealthOption) withDefaults() *HealthOption {
+	o := *DefaultHealthOption
+	if opt == nil {
+		return &o
+	}
+	if opt.Interval > 0 {
+		o.Interval = opt.Interval
+	}
+	if opt.Timeout > 0 {
+		o.Timeout = opt.Timeout
+	}
+	if opt.MaxFailures > 0 {
+		o.MaxFailures = opt.MaxFailures
+	}
+	if opt.EjectDuration > 0 {
+		o.EjectDuration = opt.EjectDuration
+	}
+	if opt.Option != nil {
+		o.Option = opt.Option
+	}
+	return &o
+}
+
+// NewHealthChecker 创建一个健康检查器，并立即在后台开始周期性探测
+func NewHealthChecker(addrs []string, opt *HealthOption) *HealthChecker {
+	hc := &HealthChecker{
+		opt:     opt.withDefaults(),
+		servers: make(map[string]*serverHealth),
+		done:    make(chan struct{}),
+	}
+	hc.Update(addrs)
+	go hc.loop()
+	return hc
+}
+
+// Update 替换需要检查的地址列表，已有地址的健康状态会被保留
+func (hc *HealthChecker) Update(addrs []string) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	servers := make(map[string]*serverHealth, len(addrs))
+	for _, addr := range addrs {
+		if s, ok := hc.servers[addr]; ok {
+			servers[addr] = s
+		} else {
+			servers[addr] = &serverHealth{}
+		}
+	}
+	hc.addrs = append([]string(nil), addrs...)
+	hc.servers = servers
+}
+
+// Alive 按原有顺序返回当前未被摘除的地址
+func (hc *HealthChecker) Alive() []string {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	now := time.Now()
+	alive := make([]string, 0, len(hc.addrs))
+	for _, addr := range hc.addrs {
+		if s := hc.servers[addr]; !s.ejectedUntil.After(now) {
+			alive = append(alive, addr)
+		}
+	}
+	return alive
+}
+
+// ReportCall 上报一次对 addr 的调用结果，err 为 nil 表示成功，被摘除的节点成功后恢复接收流量
+// 连续失败达到 MaxFailures 次的节点会被摘除 EjectDuration
+func (hc *HealthChecker) ReportCall(addr string, err error) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	s, ok := hc.servers[addr]
+	if !ok {
+		return
+	}
+	if err == nil {
+		// 一次成功（包括主动探测）说明节点已经恢复，立即结束摘除
+		s.failures = 0
+		s.ejectedUntil = time.Time{}
+		return
+	}
+	s.failures++
+	if s.failures >= hc.opt.MaxFailures {
+		s.failures = 0
+		s.ejectedUntil = time.Now().Add(hc.opt.EjectDuration)
+	}
+}
+
+// Close 停止后台探测
+func (hc *HealthChecker) Close() error {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	if hc.closed {
+		return ErrShutdown
+	}
+	hc.closed = true
+	close(hc.done)
+	return nil
+}
+
+func (hc *HealthChecker) loop() {
+	ticker := time.NewTicker(hc.opt.Interval)
+	defer ticker.Stop()
+	for {
+		hc.checkAll()
+		select {
+		case <-ticker.C:
+		case <-hc.done:
+			return
+		}
+	}
+}
+
+// checkAll 并发探测所有地址，等待本轮全部结束
+func (hc *HealthChecker) checkAll() {
+	hc.mu.Lock()
+	addrs := append([]string(nil), hc.addrs...)
+	hc.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, addr := range addrs {
+		wg.Add(1)
+		go func(addr string) {
+			defer wg.Done()
+			hc.ReportCall(addr, hc.check(addr))
+		}(addr)
+	}
+	wg.Wait()
+}
+
+// check 与 addr 建立一个新连接并调用 Health.Check，只有回复 SERVING 才算成功
+// 建立连接、协议交换与 Health.Check 共用同一个截止时间，整个探测不超过 Timeout
+func (hc *HealthChecker) check(addr string) error {
+	deadline := time.Now().Add(hc.opt.Timeout)
+	conn, err := net.DialTimeout("tcp", addr, hc.opt.Timeout)
+	if err != nil {
+		return err
+	}
+	// 连接上的读写同样受截止时间约束，避免 NewClient 写入 Option 时阻塞
+	_ = conn.SetDeadline(deadline)
+	opt, err := parseOptions(hc.opt.Option)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	client, err := NewClient(conn, opt)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = client.Close() }()
+
+	var status string
+	call := client.Go(HealthCheckMethod, "", &status, nil)
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return ErrHealthCheckTimeout
+	case call = <-call.Done:
+		if call.Error != nil {
+			return call.Error
+		}
+	}
+	if status != Serving {
+		return fmt.Errorf("rpc health: %s is %s", addr, status)
+	}
+	return nil
+}
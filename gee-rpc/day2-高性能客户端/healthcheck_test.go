@@ -0,0 +1,143 @@
+package geerpc
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitFor 轮询 cond，直到其返回 true 或超时
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServingStatus(t *testing.T) {
+	server := NewServer()
+	if server.ServingStatus() != Serving {
+		t.Fatalf("expect %s by default", Serving)
+	}
+	server.SetServingStatus(false)
+	if server.ServingStatus() != NotServing {
+		t.Fatalf("expect %s", NotServing)
+	}
+	server.SetServingStatus(true)
+	if server.ServingStatus() != Serving {
+		t.Fatalf("expect %s", Serving)
+	}
+}
+
+func TestHealthCheckerServingTransition(t *testing.T) {
+	server := NewServer()
+	server.SetServingStatus(false)
+	addr := startServer(t, server)
+
+	// 摘除时长远大于测试时间，节点只能通过成功的探测恢复
+	hc := NewHealthChecker([]string{addr}, &HealthOption{
+		Interval:      20 * time.Millisecond,
+		Timeout:       time.Second,
+		MaxFailures:   1,
+		EjectDuration: time.Hour,
+	})
+	defer func() { _ = hc.Close() }()
+
+	waitFor(t, "NOT_SERVING server to be ejected", func() bool { return len(hc.Alive()) == 0 })
+	server.SetServingStatus(true)
+	waitFor(t, "SERVING server to be restored", func() bool { return len(hc.Alive()) == 1 })
+}
+
+func TestHealthCheckerEjectionExpiry(t *testing.T) {
+	// 不启动后台探测，只通过 ReportCall 驱动状态
+	hc := &HealthChecker{
+		opt:     &HealthOption{MaxFailures: 2, EjectDuration: 50 * time.Millisecond},
+		servers: make(map[string]*serverHealth),
+	}
+	hc.Update([]string{"a", "b"})
+
+	boom := errors.New("boom")
+	hc.ReportCall("a", boom)
+	if alive := hc.Alive(); len(alive) != 2 {
+		t.Fatalf("one failure should not eject, alive %v", alive)
+	}
+	hc.ReportCall("a", boom)
+	if alive := hc.Alive(); len(alive) != 1 || alive[0] != "b" {
+		t.Fatalf("expect a ejected, alive %v", alive)
+	}
+	waitFor(t, "ejection to expire", func() bool { return len(hc.Alive()) == 2 })
+
+	hc.ReportCall("a", boom)
+	hc.ReportCall("a", boom)
+	hc.ReportCall("a", nil)
+	if alive := hc.Alive(); len(alive) != 2 {
+		t.Fatalf("success should end ejection, alive %v", alive)
+	}
+}
+
+func TestHealthCheckTimeout(t *testing.T) {
+	// 接受连接但从不回复的服务端
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = l.Close() }()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer func() { _ = conn.Close() }()
+		}
+	}()
+
+	timeout := 100 * time.Millisecond
+	hc := &HealthChecker{opt: &HealthOption{Timeout: timeout}}
+	start := time.Now()
+	if err := hc.check(l.Addr().String()); err == nil {
+		t.Fatal("expect error from unresponsive server")
+	}
+	if elapsed := time.Since(start); elapsed > timeout+timeout/2 {
+		t.Fatalf("probe took %v, longer than Timeout %v", elapsed, timeout)
+	}
+}
+
+func TestHealthOptionDefaults(t *testing.T) {
+	opt := (&HealthOption{Timeout: time.Second}).withDefaults()
+	want := *DefaultHealthOption
+	want.Timeout = time.Second
+	if *opt != want {
+		t.Fatalf("got %+v, want %+v", *opt, want)
+	}
+	if opt := (*HealthOption)(nil).withDefaults(); *opt != *DefaultHealthOption {
+		t.Fatalf("nil option: got %+v", *opt)
+	}
+
+	// 只设置部分字段时后台探测不应 panic（Interval 为 0 时 time.NewTicker 会 panic）
+	addr := startServer(t, NewServer())
+	hc := NewHealthChecker([]string{addr}, &HealthOption{Timeout: time.Second})
+	defer func() { _ = hc.Close() }()
+	if alive := hc.Alive(); len(alive) != 1 {
+		t.Fatalf("expect server alive, got %v", alive)
+	}
+}
+
+func TestHealthCheckUsesOption(t *testing.T) {
+	addr := startServer(t, NewServer())
+	hc := &HealthChecker{opt: (&HealthOption{Timeout: time.Second}).withDefaults()}
+	if err := hc.check(addr); err != nil {
+		t.Fatalf("default option: %v", err)
+	}
+	// 使用调用方传入的 Option：不存在的编解码方式使探测失败
+	hc.opt.Option = &Option{CodecType: "application/unknown"}
+	if err := hc.check(addr); err == nil || !strings.Contains(err.Error(), "invalid codec type") {
+		t.Fatalf("expect invalid codec type error, got %v", err)
+	}
+}
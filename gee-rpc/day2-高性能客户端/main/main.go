@@ -1,14 +1,11 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"geerpc"
-
-	"geerpc/codec"
 	"log"
 	"net"
-	"time"
+	"sync"
 )
 
 // startServer 函数启动一个RPC服务器，并监听端口 8080。它将服务器的地址发送到一个通道 addr 中，以便在 main 函数中获取
@@ -28,28 +25,23 @@ func main() {
 	addr := make(chan string)
 	go startServer(addr)
 
-	// 事实上，下面的代码就像一个简单的geerpc客户端
-
-	// 通过 <-addr 从通道中获取服务器地址，并使用 net.Dial 连接到RPC服务器
-	conn, _ := net.Dial("tcp", <-addr)
-	defer func() { _ = conn.Close() }()
+	// 使用 geerpc.Dial 完成协议交换，Option 以帧的形式发送，之后可以立即发送请求
+	client, _ := geerpc.Dial("tcp", <-addr)
+	defer func() { _ = client.Close() }()
 
-	time.Sleep(time.Second)
-	// 通过 json.NewEncoder 发送默认的选项（geerpc.DefaultOption）到服务器
-	_ = json.NewEncoder(conn).Encode(geerpc.DefaultOption)
-	cc := codec.NewGobCodec(conn)
 	// send request & receive response
+	var wg sync.WaitGroup
 	for i := 0; i < 5; i++ {
-		h := &codec.Header{
-			ServiceMethod: "Foo.Sum",
-			Seq:           uint64(i),
-		}
-		_ = cc.Write(h, fmt.Sprintf("geerpc req %d", h.Seq))
-		_ = cc.ReadHeader(h)
-		var reply string
-		_ = cc.ReadBody(&reply)
-		log.Println("reply:", reply)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			args := fmt.Sprintf("geerpc req %d", i)
+			var reply string
+			if err := client.Call("Foo.Sum", args, &reply); err != nil {
+				log.Fatal("call Foo.Sum error:", err)
+			}
+			log.Println("reply:", reply)
+		}(i)
 	}
-	// 客户端首先发送 Option 进行协议交换，接下来发送消息头 h := &codec.Header{}，和消息体 geerpc req ${h.Seq}。
-	// 最后解析服务端的响应 reply，并打印出来。
+	wg.Wait()
 }
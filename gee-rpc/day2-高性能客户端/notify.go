@@ -0,0 +1,70 @@
+package geerpc
+
+import (
+	"geerpc/codec"
+	"log"
+	"sync"
+)
+
+// Conn 代表服务端上一个已完成协议交换的连接，可以通过它向客户端推送单向通知
+type Conn struct {
+	cc      codec.Codec
+	sending *sync.Mutex // 与该连接上的响应共用，protect following
+	closed  bool
+}
+
+// Notify 向该连接的客户端推送一条单向通知，客户端不会回复
+// 通知的 Header 中 Seq 为 0 且 Notify 为 true，客户端据此与普通响应区分
+func (c *Conn) Notify(method string, body interface{}) error {
+	c.sending.Lock()
+	defer c.sending.Unlock()
+	if c.closed {
+		return ErrShutdown
+	}
+	h := &codec.Header{ServiceMethod: method, Notify: true}
+	return c.cc.Write(h, body)
+}
+
+// OnConnect 注册一个回调，每当有新连接完成协议交换时被调用
+// 回调在开始读取请求之前同步执行，不应长时间阻塞
+func (server *Server) OnConnect(fn func(*Conn)) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.onConnect = fn
+}
+
+// Broadcast 向当前所有连接推送同一条通知，单个连接发送失败只记录日志
+func (server *Server) Broadcast(method string, body interface{}) {
+	server.mu.Lock()
+	conns := make([]*Conn, 0, len(server.conns))
+	for conn := range server.conns {
+		conns = append(conns, conn)
+	}
+	server.mu.Unlock()
+
+	for _, conn := range conns {
+		if err := conn.Notify(method, body); err != nil {
+			log.Println("rpc server: notify error:", err)
+		}
+	}
+}
+
+func (server *Server) addConn(conn *Conn) {
+	server.mu.Lock()
+	server.conns[conn] = struct{}{}
+	onConnect := server.onConnect
+	server.mu.Unlock()
+	if onConnect != nil {
+		onConnect(conn)
+	}
+}
+
+func (server *Server) removeConn(conn *Conn) {
+	server.mu.Lock()
+	delete(server.conns, conn)
+	server.mu.Unlock()
+
+	conn.sending.Lock()
+	conn.closed = true
+	conn.sending.Unlock()
+}
@@ -0,0 +1,174 @@
+package geerpc
+
+import (
+	"errors"
+	"geerpc/codec"
+	"net"
+	"testing"
+	"time"
+)
+
+func startServer(t *testing.T, server *Server) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	go server.Accept(l)
+	return l.Addr().String()
+}
+
+func TestBroadcast(t *testing.T) {
+	server := NewServer()
+	connected := make(chan *Conn, 2)
+	server.OnConnect(func(c *Conn) { connected <- c })
+	addr := startServer(t, server)
+
+	received := make(chan string, 2)
+	for i := 0; i < 2; i++ {
+		client, err := Dial("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = client.Close() }()
+		if err := client.OnNotify("Cache.Invalidate", func(key string) { received <- key }); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-connected:
+		case <-time.After(time.Second):
+			t.Fatal("OnConnect not called")
+		}
+	}
+
+	server.Broadcast("Cache.Invalidate", "user:1")
+	for i := 0; i < 2; i++ {
+		select {
+		case key := <-received:
+			if key != "user:1" {
+				t.Fatalf("expect user:1, got %q", key)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("client %d did not receive the broadcast", i)
+		}
+	}
+}
+
+func TestNotifyAfterDisconnect(t *testing.T) {
+	server := NewServer()
+	connected := make(chan *Conn, 1)
+	server.OnConnect(func(c *Conn) { connected <- c })
+	addr := startServer(t, server)
+
+	client, err := Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := <-connected
+	_ = client.Close()
+
+	// 连接被移除后 Notify 返回 ErrShutdown，而不是写入已关闭的编解码器
+	deadline := time.Now().Add(time.Second)
+	for {
+		err := conn.Notify("Cache.Invalidate", "k")
+		if errors.Is(err, ErrShutdown) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expect ErrShutdown after disconnect, got %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	server.Broadcast("Cache.Invalidate", "k") // 没有连接时不应出错
+}
+
+// pipeClient 返回一个通过 net.Pipe 连接到测试代码扮演的服务端的客户端，服务端一侧已完成协议交换
+func pipeClient(t *testing.T) (*Client, codec.Codec) {
+	t.Helper()
+	clientConn, serverConn := net.Pipe()
+	handshake := make(chan error, 1)
+	go func() {
+		_, err := readOption(serverConn)
+		handshake <- err
+	}()
+	client, err := NewClient(clientConn, DefaultOption)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-handshake; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client, codec.NewGobCodec(serverConn)
+}
+
+// notifyDuringCall 在收到请求之后、发送响应之前推送一条通知，检查通知被处理且调用正常完成
+func notifyDuringCall(t *testing.T, client *Client, cc codec.Codec) {
+	t.Helper()
+	done := make(chan error, 1)
+	var reply string
+	go func() { done <- client.Call("Foo.Sum", "a", &reply) }()
+
+	var h codec.Header
+	var arg string
+	if err := cc.ReadHeader(&h); err != nil {
+		t.Fatal(err)
+	}
+	if err := cc.ReadBody(&arg); err != nil {
+		t.Fatal(err)
+	}
+	if err := cc.Write(&codec.Header{ServiceMethod: "Cache.Invalidate", Notify: true}, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cc.Write(&h, "resp "+arg); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil || reply != "resp a" {
+			t.Fatalf("expect resp a, got %q, %v", reply, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("call did not complete")
+	}
+}
+
+func TestNotifyDuringCall(t *testing.T) {
+	client, cc := pipeClient(t)
+	received := make(chan string, 1)
+	if err := client.OnNotify("Cache.Invalidate", func(key *string) { received <- *key }); err != nil {
+		t.Fatal(err)
+	}
+	notifyDuringCall(t, client, cc)
+	select {
+	case key := <-received:
+		if key != "k" {
+			t.Fatalf("expect k, got %q", key)
+		}
+	default:
+		t.Fatal("notification handled after the response, expect before")
+	}
+}
+
+func TestNotifyHandlerPanic(t *testing.T) {
+	client, cc := pipeClient(t)
+	if err := client.OnNotify("Cache.Invalidate", func(string) { panic("boom") }); err != nil {
+		t.Fatal(err)
+	}
+	notifyDuringCall(t, client, cc)
+	// 处理函数 panic 后客户端仍然可用
+	notifyDuringCall(t, client, cc)
+	if !client.IsAvailable() {
+		t.Fatal("client should remain available")
+	}
+}
+
+func TestOnNotifyRejectsBadHandler(t *testing.T) {
+	client, _ := pipeClient(t)
+	for _, handler := range []interface{}{"not a func", func() {}, func(a, b string) {}, func(string) error { return nil }} {
+		if err := client.OnNotify("X.Y", handler); err == nil {
+			t.Errorf("expect error for handler %T", handler)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package geerpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"geerpc/codec"
+	"io"
+	"sync"
+)
+
+// replayer 保存录制下来的请求与响应，按 ServiceMethod + 参数匹配
+type replayer struct {
+	mu        sync.Mutex                 // protect following
+	responses map[string][]*codec.Record // 同一请求录制了多次时按顺序回放，最后一条重复使用
+}
+
+// NewReplayServer 根据 codec.RecordingCodec 录制的记录创建一个回放服务器
+// 客户端或服务端任一侧的录制都可以使用：同一 Seq 的第一条记录视为请求，第二条视为响应。
+// 收到的请求若在录制中找不到相同的 ServiceMethod 和参数，将返回错误。
+// 响应按录制时的具体类型回放，回放进程需要用 gob.Register 注册基本类型以外的响应类型
+func NewReplayServer(r io.Reader) (*Server, error) {
+	records, err := codec.ReadRecords(r)
+	if err != nil {
+		return nil, err
+	}
+	rp := &replayer{responses: make(map[string][]*codec.Record)}
+	requests := make(map[uint64]*codec.Record)
+	for i := range records {
+		rec := &records[i]
+		if rec.Header.Notify {
+			continue
+		}
+		req, ok := requests[rec.Header.Seq]
+		if !ok {
+			requests[rec.Header.Seq] = rec
+			continue
+		}
+		delete(requests, rec.Header.Seq)
+		key := replayKey(req.Header.ServiceMethod, req.Body)
+		rp.responses[key] = append(rp.responses[key], rec)
+	}
+	server := NewServer()
+	server.replay = rp
+	return server, nil
+}
+
+func replayKey(serviceMethod string, args json.RawMessage) string {
+	return serviceMethod + " " + string(args)
+}
+
+// reply 返回与 serviceMethod 和 argv 匹配的录制响应，录制的响应带有错误时原样返回该错误
+func (rp *replayer) reply(serviceMethod string, argv interface{}) (interface{}, error) {
+	args, err := json.Marshal(argv)
+	if err != nil {
+		return nil, err
+	}
+	key := replayKey(serviceMethod, args)
+	rp.mu.Lock()
+	recs := rp.responses[key]
+	if len(recs) == 0 {
+		rp.mu.Unlock()
+		return nil, fmt.Errorf("rpc replay: no recorded response for %s(%s)", serviceMethod, args)
+	}
+	rec := recs[0]
+	if len(recs) > 1 {
+		rp.responses[key] = recs[1:]
+	}
+	rp.mu.Unlock()
+
+	if rec.Header.Error != "" {
+		return nil, errors.New(rec.Header.Error)
+	}
+	if len(rec.Gob) > 0 {
+		reply, err := rec.DecodeBody()
+		if err != nil {
+			return nil, fmt.Errorf("rpc replay: decode recorded reply of %s: %v", serviceMethod, err)
+		}
+		return reply, nil
+	}
+	// 只有 JSON body 的记录（例如手写的记录）只能还原出 JSON 的基本类型，适用于字符串等简单响应
+	var reply interface{}
+	if err := json.Unmarshal(rec.Body, &reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
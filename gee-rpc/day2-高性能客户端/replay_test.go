@@ -0,0 +1,146 @@
+package geerpc
+
+import (
+	"bytes"
+	"geerpc/codec"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testRecords = `{"Dir":"write","Header":{"ServiceMethod":"Foo.Sum","Seq":1},"Body":"a"}
+{"Dir":"read","Header":{"ServiceMethod":"Foo.Sum","Seq":1},"Body":"resp a"}
+{"Dir":"write","Header":{"ServiceMethod":"Foo.Sum","Seq":2},"Body":"b"}
+{"Dir":"read","Header":{"ServiceMethod":"Foo.Sum","Seq":2,"Error":"boom"},"Body":null}
+{"Dir":"read","Header":{"ServiceMethod":"Cache.Invalidate","Notify":true},"Body":"k"}
+`
+
+func TestReplayServer(t *testing.T) {
+	server, err := NewReplayServer(strings.NewReader(testRecords))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = l.Close() }()
+	go server.Accept(l)
+
+	client, err := Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = client.Close() }()
+
+	var reply string
+	if err := client.Call("Foo.Sum", "a", &reply); err != nil || reply != "resp a" {
+		t.Fatalf("expect recorded reply, got %q, %v", reply, err)
+	}
+	if err := client.Call("Foo.Sum", "b", &reply); err == nil || err.Error() != "boom" {
+		t.Fatalf("expect recorded error boom, got %v", err)
+	}
+	if err := client.Call("Foo.Sum", "c", &reply); err == nil {
+		t.Fatal("expect error for unrecorded args")
+	}
+}
+
+type replayProfile struct {
+	Name string
+	Age  int
+	Tags []string
+}
+
+// serveRecorded 是一个录制自身收发的简单服务端，按方法返回不同类型的响应
+func serveRecorded(t *testing.T, l net.Listener, w *bytes.Buffer, done *sync.WaitGroup) {
+	defer done.Done()
+	conn, err := l.Accept()
+	if err != nil {
+		return
+	}
+	if _, err := readOption(conn); err != nil {
+		t.Error(err)
+		return
+	}
+	cc := codec.NewRecordingCodec(codec.NewGobCodec(conn), w)
+	defer func() { _ = cc.Close() }()
+	for {
+		var h codec.Header
+		if err := cc.ReadHeader(&h); err != nil {
+			return
+		}
+		var arg string
+		if err := cc.ReadBody(&arg); err != nil {
+			return
+		}
+		var reply interface{}
+		switch h.ServiceMethod {
+		case "Calc.Len":
+			reply = len(arg)
+		case "User.Get":
+			reply = &replayProfile{Name: arg, Age: 30, Tags: []string{"a", "b"}}
+		default:
+			reply = "hello " + arg
+		}
+		if err := cc.Write(&h, reply); err != nil {
+			return
+		}
+	}
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	// 录制：客户端与真实的服务端通信，服务端一侧录制
+	var recording bytes.Buffer
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var done sync.WaitGroup
+	done.Add(1)
+	go serveRecorded(t, l, &recording, &done)
+
+	client, err := Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := replayProfile{Name: "geektutu", Age: 30, Tags: []string{"a", "b"}}
+	call := func(client *Client) {
+		t.Helper()
+		var n int
+		if err := client.Call("Calc.Len", "geerpc", &n); err != nil || n != 6 {
+			t.Fatalf("Calc.Len: got %d, %v", n, err)
+		}
+		var p replayProfile
+		if err := client.Call("User.Get", "geektutu", &p); err != nil || !reflect.DeepEqual(p, want) {
+			t.Fatalf("User.Get: got %+v, %v", p, err)
+		}
+		var s string
+		if err := client.Call("Echo.Say", "gee", &s); err != nil || s != "hello gee" {
+			t.Fatalf("Echo.Say: got %q, %v", s, err)
+		}
+	}
+	call(client)
+	_ = client.Close()
+	_ = l.Close()
+	done.Wait()
+
+	// 回放：同样的调用得到相同类型的响应
+	server, err := NewReplayServer(&recording)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rl, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = rl.Close() }()
+	go server.Accept(rl)
+	replayClient, err := Dial("tcp", rl.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = replayClient.Close() }()
+	call(replayClient)
+}
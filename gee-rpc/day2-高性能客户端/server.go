@@ -1,7 +1,9 @@
 package geerpc
 
 import (
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"geerpc/codec"
 	"io"
@@ -9,6 +11,7 @@ import (
 	"net"
 	"reflect"
 	"sync"
+	"sync/atomic"
 )
 
 const MagicNumber = 0x3bef5c
@@ -24,12 +27,60 @@ var DefaultOption = &Option{
 	CodecType:   codec.GobType,
 }
 
+// 协议交换时 Option 以帧的形式发送：4 字节大端序长度 + JSON 编码的 Option
+// 服务端只读取帧内的字节，之后的字节全部留给 Codec，避免 json.Decoder 的缓冲吞掉 Codec 的数据
+const maxOptionSize = 4096
+
+var errOptionTooLarge = errors.New("option frame too large")
+
+// writeOption 将 opt 编码为一帧，一次性写入 w
+func writeOption(w io.Writer, opt *Option) error {
+	data, err := json.Marshal(opt)
+	if err != nil {
+		return err
+	}
+	frame := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(len(data)))
+	copy(frame[4:], data)
+	_, err = w.Write(frame)
+	return err
+}
+
+// readOption 从 r 中恰好读取一帧并解码为 Option
+func readOption(r io.Reader) (*Option, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxOptionSize {
+		return nil, errOptionTooLarge
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	var opt Option
+	if err := json.Unmarshal(data, &opt); err != nil {
+		return nil, err
+	}
+	return &opt, nil
+}
+
 // Server代表一个RPC服务器。
-type Server struct{}
+type Server struct {
+	mu        sync.Mutex // protect following
+	conns     map[*Conn]struct{} // 当前所有活跃的连接，用于广播通知
+	onConnect func(*Conn)
+
+	notServing atomic.Bool // 健康检查状态，零值表示 SERVING
+
+	replay *replayer // 非空时表示回放服务器，按录制的记录响应请求
+}
 
 // NewServer 返回一个新服务器
 func NewServer() *Server {
-	return &Server{}
+	return &Server{conns: make(map[*Conn]struct{})}
 }
 
 // DefaultServer 是 *Server 的默认实例
@@ -39,9 +90,9 @@ var DefaultServer = NewServer()
 // ServeConn 阻塞，为连接提供服务，直到客户端挂断
 func (server *Server) ServeConn(conn io.ReadWriteCloser) {
 	defer func() { _ = conn.Close() }()
-	// 通过json.NewDecoder解码连接中的选项信息，并存储在opt变量中
-	var opt Option
-	if err := json.NewDecoder(conn).Decode(&opt); err != nil {
+	// 读取连接开头的 Option 帧，帧之后的字节交给编解码器
+	opt, err := readOption(conn)
+	if err != nil {
 		log.Println("rpc server: options error: ", err)
 		return
 	}
@@ -67,6 +118,9 @@ var invalidRequest = struct{}{}
 func (server *Server) serveCodec(cc codec.Codec) {
 	// sending 是一个互斥锁，用于确保在发送完整的响应之前不会有其他响应被发送。这是因为在并发情况下，可能会有多个请求同时到达服务器
 	sending := new(sync.Mutex) // make sure to send a complete response
+	// 通知与响应共用同一把 sending 锁，保证二者不会在流上交错
+	conn := &Conn{cc: cc, sending: sending}
+	server.addConn(conn)
 	// wg 是一个等待组，用于等待所有的请求都被处理完毕。在每处理一个请求时，都会通过 wg.Add(1) 增加计数，处理完成时通过 wg.Done() 减少计数。最后，通过 wg.Wait() 等待所有请求的完成。
 	wg := new(sync.WaitGroup)  // wait until all request are handled
 	for {
@@ -83,6 +137,8 @@ func (server *Server) serveCodec(cc codec.Codec) {
 		go server.handleRequest(cc, req, sending, wg)
 	}
 	wg.Wait()
+	// 先移除连接并标记为已关闭，再关闭编解码器，避免 Broadcast 向已关闭的编解码器写入
+	server.removeConn(conn)
 	_ = cc.Close()
 }
 
@@ -129,6 +185,22 @@ func (server *Server) sendResponse(cc codec.Codec, h *codec.Header, body interfa
 func (server *Server) handleRequest(cc codec.Codec, req *request, sending *sync.Mutex, wg *sync.WaitGroup) {
 	// TODO，应该调用注册的 rpc 方法来获得正确的回复v 第一天，只需打印 argv 并发送一条 hello 消息
 	defer wg.Done()
+	// 内置的健康检查服务，每个 Server 都会响应
+	if req.h.ServiceMethod == HealthCheckMethod {
+		req.replyv = reflect.ValueOf(server.ServingStatus())
+		server.sendResponse(cc, req.h, req.replyv.Interface(), sending)
+		return
+	}
+	if server.replay != nil {
+		reply, err := server.replay.reply(req.h.ServiceMethod, req.argv.Elem().Interface())
+		if err != nil {
+			req.h.Error = err.Error()
+			server.sendResponse(cc, req.h, invalidRequest, sending)
+			return
+		}
+		server.sendResponse(cc, req.h, reply, sending)
+		return
+	}
 	log.Println(req.h, req.argv.Elem())
 	req.replyv = reflect.ValueOf(fmt.Sprintf("geerpc resp %d", req.h.Seq))
 	server.sendResponse(cc, req.h, req.replyv.Interface(), sending)
@@ -0,0 +1,199 @@
+package geerpc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"geerpc/codec"
+	"io"
+	"log"
+	"net"
+	"os"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// 畸形输入会让服务端打印大量日志，测试中丢弃
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// optionFrame 返回 opt 编码后的帧
+func optionFrame(t testing.TB, opt interface{}) []byte {
+	data, err := json.Marshal(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rawFrame(uint32(len(data)), data)
+}
+
+func rawFrame(size uint32, data []byte) []byte {
+	frame := make([]byte, 4, 4+len(data))
+	binary.BigEndian.PutUint32(frame, size)
+	return append(frame, data...)
+}
+
+// gobRequests 返回一组请求按 gob 编码后的字节流
+func gobRequests(t testing.TB, bodies ...interface{}) []byte {
+	var buf bytes.Buffer
+	cc := codec.NewGobCodec(nopCloser{&buf})
+	for i, body := range bodies {
+		h := &codec.Header{ServiceMethod: "Foo.Sum", Seq: uint64(i + 1)}
+		if err := cc.Write(h, body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+type nopCloser struct{ io.ReadWriter }
+
+func (nopCloser) Close() error { return nil }
+
+// bytesConn 是一个内存中的连接：服务端从 input 读取，读完即为 EOF（相当于客户端发送完后挂断），
+// 写入的响应保存在 output 中。与 net.Pipe 不同，写入方不会因服务端停止读取而阻塞，也不需要等待固定的时间
+type bytesConn struct {
+	io.Reader
+	mu     sync.Mutex // protect output
+	output bytes.Buffer
+}
+
+func (c *bytesConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.output.Write(p)
+}
+
+func (c *bytesConn) Close() error { return nil }
+
+// serveBytes 把 input 喂给 ServeConn，等待 ServeConn 在读到 EOF 后处理完所有请求并返回，
+// 返回服务端写回的全部字节。ServeConn 未能及时返回视为 goroutine 泄漏
+func serveBytes(t testing.TB, input []byte) []byte {
+	conn := &bytesConn{Reader: bytes.NewReader(input)}
+	served := make(chan struct{})
+	go func() {
+		NewServer().ServeConn(conn)
+		close(served)
+	}()
+	select {
+	case <-served:
+	case <-time.After(time.Second):
+		t.Fatalf("ServeConn did not return after client hung up, input %q", input)
+	}
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	return conn.output.Bytes()
+}
+
+func TestServeConnConformance(t *testing.T) {
+	valid := optionFrame(t, DefaultOption)
+	tests := []struct {
+		name      string
+		input     []byte
+		responses int // 期望收到的完整响应数
+	}{
+		{"empty", nil, 0},
+		{"truncated frame size", []byte{0, 0}, 0},
+		{"truncated option", valid[:len(valid)-1], 0},
+		{"oversized option", rawFrame(maxOptionSize+1, nil), 0},
+		{"huge option size", rawFrame(1<<32-1, []byte("{")), 0},
+		{"option is not json", rawFrame(3, []byte("abc")), 0},
+		{"invalid magic number", optionFrame(t, &Option{MagicNumber: 1, CodecType: codec.GobType}), 0},
+		{"invalid codec type", optionFrame(t, &Option{MagicNumber: MagicNumber, CodecType: "x"}), 0},
+		{"malformed header", append(append([]byte{}, valid...), 0xff, 0xff, 0xff), 0},
+		{"handshake only", valid, 0},
+		// 请求紧跟在 Option 之后发送，不能被握手阶段吞掉
+		{"pipelined requests", append(append([]byte{}, valid...), gobRequests(t, "a", "b", "c")...), 3},
+		{"malformed body", append(append([]byte{}, valid...), gobRequests(t, 42)...), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := serveBytes(t, tt.input)
+			cc := codec.NewGobCodec(nopCloser{bytes.NewBuffer(output)})
+			n := 0
+			for {
+				var h codec.Header
+				if err := cc.ReadHeader(&h); err != nil {
+					break
+				}
+				var reply string
+				if err := cc.ReadBody(&reply); err != nil {
+					break
+				}
+				n++
+			}
+			if n != tt.responses {
+				t.Fatalf("expect %d responses, got %d", tt.responses, n)
+			}
+		})
+	}
+}
+
+func TestServeConnNoGoroutineLeak(t *testing.T) {
+	before := runtime.NumGoroutine()
+	valid := optionFrame(t, DefaultOption)
+	for i := 0; i < 20; i++ {
+		serveBytes(t, append(append([]byte{}, valid...), gobRequests(t, "a", 1, "b")...))
+		serveBytes(t, rawFrame(maxOptionSize+1, nil))
+	}
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatalf("goroutines leaked: before %d, after %d", before, after)
+	}
+}
+
+func TestClientCallImmediatelyAfterDial(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = l.Close() }()
+	go NewServer().Accept(l)
+
+	for i := 0; i < 10; i++ {
+		client, err := Dial("tcp", l.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var reply string
+		call := client.Go("Foo.Sum", "x", &reply, nil)
+		select {
+		case call = <-call.Done:
+			if call.Error != nil {
+				t.Fatal(call.Error)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("call right after handshake timed out")
+		}
+		_ = client.Close()
+	}
+}
+
+func FuzzServeConn(f *testing.F) {
+	valid := optionFrame(f, DefaultOption)
+	f.Add([]byte{})
+	f.Add(valid)
+	f.Add(rawFrame(maxOptionSize+1, nil))
+	f.Add(append(append([]byte{}, valid...), gobRequests(f, "a")...))
+	f.Fuzz(func(t *testing.T, input []byte) {
+		serveBytes(t, input)
+	})
+}
+
+// FuzzServeConnAfterHandshake 在合法的握手之后喂入任意字节，覆盖 header 与 body 的解码
+func FuzzServeConnAfterHandshake(f *testing.F) {
+	valid := optionFrame(f, DefaultOption)
+	f.Add([]byte{})
+	f.Add(gobRequests(f, "a"))
+	f.Add(gobRequests(f, "a", 1, "b"))
+	f.Add([]byte{0xff, 0xff, 0xff})
+	f.Fuzz(func(t *testing.T, input []byte) {
+		serveBytes(t, append(append([]byte{}, valid...), input...))
+	})
+}
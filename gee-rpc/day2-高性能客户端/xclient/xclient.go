@@ -0,0 +1,195 @@
+// 支持多个服务端实例的客户端，负责选择节点与复用连接
+package xclient
+
+import (
+	"errors"
+	"geerpc"
+	"geerpc/consistenthash"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SelectMode 代表不同的负载均衡策略
+type SelectMode int
+
+const (
+	RandomSelect         SelectMode = iota // 随机选择
+	RoundRobinSelect                       // 轮询选择
+	ConsistentHashSelect                   // 按路由 key 一致性哈希选择，相同的 key 总是落到同一节点
+)
+
+// defaultReplicas 与 geecache 保持一致，每个真实节点对应的虚拟节点数
+const defaultReplicas = 50
+
+var ErrNoAvailableServer = errors.New("rpc xclient: no available servers")
+
+// XClient 在一组服务端地址之间按 SelectMode 选择节点发起调用
+type XClient struct {
+	mode SelectMode
+	opt  *geerpc.Option
+
+	mu        sync.Mutex            // protect following
+	health    *geerpc.HealthChecker // 可选，非空时只在健康的节点中选择，并上报调用结果
+	addrs     []string
+	r         *rand.Rand
+	index     int                 // 轮询的位置
+	ring      *consistenthash.Map // 按 ringAddrs 构建的哈希环
+	ringAddrs string              // 构建 ring 时的地址列表，变化时重建
+	clients   map[string]*geerpc.Client
+}
+
+// NewXClient 创建一个 XClient，opt 为 nil 时使用 geerpc.DefaultOption
+func NewXClient(addrs []string, mode SelectMode, opt *geerpc.Option) *XClient {
+	if opt == nil {
+		opt = geerpc.DefaultOption
+	}
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return &XClient{
+		mode:    mode,
+		opt:     opt,
+		addrs:   append([]string(nil), addrs...),
+		r:       r,
+		index:   r.Intn(1 << 30), // 从随机位置开始轮询，避免所有客户端都先打到第一个节点
+		clients: make(map[string]*geerpc.Client),
+	}
+}
+
+// SetHealthChecker 让 XClient 跳过被 hc 摘除的节点，并把每次调用的结果上报给 hc
+// 创建 hc 时应把 XClient 的 Option 传给 HealthOption.Option，使探测与调用使用相同的编解码方式
+func (xc *XClient) SetHealthChecker(hc *geerpc.HealthChecker) {
+	xc.mu.Lock()
+	defer xc.mu.Unlock()
+	xc.health = hc
+}
+
+// Update 替换服务端地址列表
+// 一致性哈希模式下，只有原本落在被移除节点（或新节点接管区间）上的 key 会改变归属
+func (xc *XClient) Update(addrs []string) {
+	xc.mu.Lock()
+	defer xc.mu.Unlock()
+	xc.addrs = append([]string(nil), addrs...)
+	if xc.health != nil {
+		xc.health.Update(addrs)
+	}
+}
+
+// available 返回当前可选的地址，调用时需持有 xc.mu
+func (xc *XClient) available() []string {
+	if xc.health == nil {
+		return xc.addrs
+	}
+	alive := make(map[string]bool)
+	for _, addr := range xc.health.Alive() {
+		alive[addr] = true
+	}
+	addrs := make([]string, 0, len(xc.addrs))
+	for _, addr := range xc.addrs {
+		if alive[addr] {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// pick 按 SelectMode 选出一个地址，key 仅在 ConsistentHashSelect 模式下使用
+func (xc *XClient) pick(key string) (string, error) {
+	xc.mu.Lock()
+	defer xc.mu.Unlock()
+	addrs := xc.available()
+	n := len(addrs)
+	if n == 0 {
+		return "", ErrNoAvailableServer
+	}
+	switch xc.mode {
+	case RandomSelect:
+		return addrs[xc.r.Intn(n)], nil
+	case RoundRobinSelect:
+		addr := addrs[xc.index%n]
+		xc.index = (xc.index + 1) % n
+		return addr, nil
+	case ConsistentHashSelect:
+		// 地址列表（包括健康状态）变化时重建哈希环
+		if joined := strings.Join(addrs, ","); xc.ring == nil || joined != xc.ringAddrs {
+			xc.ring = consistenthash.New(defaultReplicas, nil)
+			xc.ring.Add(addrs...)
+			xc.ringAddrs = joined
+		}
+		return xc.ring.Get(key), nil
+	default:
+		return "", errors.New("rpc xclient: not supported select mode")
+	}
+}
+
+// dial 返回 addr 上可用的客户端，必要时重新建立连接
+// 建立连接时不持有 xc.mu，一个缓慢或不可达的节点不会阻塞对其他节点的调用；
+// 多个 goroutine 同时为同一地址建立连接时，只保留先存入的一个
+func (xc *XClient) dial(addr string) (*geerpc.Client, error) {
+	xc.mu.Lock()
+	client, ok := xc.clients[addr]
+	if ok && !client.IsAvailable() {
+		_ = client.Close()
+		delete(xc.clients, addr)
+		client = nil
+	}
+	xc.mu.Unlock()
+	if client != nil {
+		return client, nil
+	}
+
+	client, err := geerpc.Dial("tcp", addr, xc.opt)
+	if err != nil {
+		return nil, err
+	}
+	xc.mu.Lock()
+	defer xc.mu.Unlock()
+	if existing, ok := xc.clients[addr]; ok && existing.IsAvailable() {
+		_ = client.Close()
+		return existing, nil
+	}
+	xc.clients[addr] = client
+	return client, nil
+}
+
+func (xc *XClient) call(addr string, serviceMethod string, args, reply interface{}) error {
+	client, err := xc.dial(addr)
+	if err == nil {
+		err = client.Call(serviceMethod, args, reply)
+	}
+	xc.mu.Lock()
+	hc := xc.health
+	xc.mu.Unlock()
+	if hc != nil {
+		hc.ReportCall(addr, err)
+	}
+	return err
+}
+
+// Call 按 SelectMode 选择一个节点发起调用
+// ConsistentHashSelect 模式下请使用 CallWithKey
+func (xc *XClient) Call(serviceMethod string, args, reply interface{}) error {
+	return xc.CallWithKey("", serviceMethod, args, reply)
+}
+
+// CallWithKey 与 Call 相同，但在 ConsistentHashSelect 模式下用 key（如用户 ID）选择节点，
+// 相同 key 的调用总是落到同一节点
+func (xc *XClient) CallWithKey(key string, serviceMethod string, args, reply interface{}) error {
+	addr, err := xc.pick(key)
+	if err != nil {
+		return err
+	}
+	return xc.call(addr, serviceMethod, args, reply)
+}
+
+// Close 关闭所有已建立的连接
+func (xc *XClient) Close() error {
+	xc.mu.Lock()
+	defer xc.mu.Unlock()
+	for key, client := range xc.clients {
+		// 关闭失败时无事可做，直接忽略
+		_ = client.Close()
+		delete(xc.clients, key)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package xclient
+
+import (
+	"fmt"
+	"geerpc"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// startServers 启动 n 个服务端，返回其地址
+func startServers(t *testing.T, n int) ([]string, []*geerpc.Server) {
+	t.Helper()
+	addrs := make([]string, n)
+	servers := make([]*geerpc.Server, n)
+	for i := range addrs {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = l.Close() })
+		servers[i] = geerpc.NewServer()
+		go servers[i].Accept(l)
+		addrs[i] = l.Addr().String()
+	}
+	return addrs, servers
+}
+
+func TestConsistentHashPick(t *testing.T) {
+	addrs := []string{"a:1", "b:2", "c:3"}
+	xc := NewXClient(addrs, ConsistentHashSelect, nil)
+	picked := make(map[string]string)
+	used := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		addr, err := xc.pick(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		picked[key] = addr
+		used[addr] = true
+		if again, _ := xc.pick(key); again != addr {
+			t.Fatalf("key %s picked %s then %s", key, addr, again)
+		}
+	}
+	if len(used) != len(addrs) {
+		t.Fatalf("expect keys spread over all servers, used %v", used)
+	}
+
+	// 移除一个节点后，只有原本落在该节点上的 key 改变归属
+	xc.Update(addrs[:2])
+	for key, before := range picked {
+		after, _ := xc.pick(key)
+		if before != addrs[2] && after != before {
+			t.Fatalf("key %s moved from %s to %s", key, before, after)
+		}
+		if after == addrs[2] {
+			t.Fatalf("key %s still picks removed server", key)
+		}
+	}
+
+	if _, err := NewXClient(nil, ConsistentHashSelect, nil).pick("k"); err != ErrNoAvailableServer {
+		t.Fatalf("expect ErrNoAvailableServer, got %v", err)
+	}
+}
+
+func TestCallWithKeySticky(t *testing.T) {
+	addrs, _ := startServers(t, 3)
+	xc := NewXClient(addrs, ConsistentHashSelect, nil)
+	defer func() { _ = xc.Close() }()
+
+	want, _ := xc.pick("user-42")
+	for i := 0; i < 5; i++ {
+		var reply string
+		if err := xc.CallWithKey("user-42", "Foo.Sum", "a", &reply); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 相同 key 的调用只会与一个节点建立连接
+	xc.mu.Lock()
+	defer xc.mu.Unlock()
+	if _, ok := xc.clients[want]; !ok || len(xc.clients) != 1 {
+		t.Fatalf("expect only a connection to %s, got %v", want, xc.clients)
+	}
+}
+
+func TestConcurrentDialKeepsOneClient(t *testing.T) {
+	addrs, _ := startServers(t, 1)
+	xc := NewXClient(addrs, RoundRobinSelect, nil)
+	defer func() { _ = xc.Close() }()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var reply string
+			errs <- xc.Call("Foo.Sum", "a", &reply)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	xc.mu.Lock()
+	defer xc.mu.Unlock()
+	if len(xc.clients) != 1 {
+		t.Fatalf("expect one cached client, got %d", len(xc.clients))
+	}
+}
+
+func TestHealthCheckerSkipsEjected(t *testing.T) {
+	addrs, servers := startServers(t, 3)
+	servers[1].SetServingStatus(false)
+	hc := geerpc.NewHealthChecker(addrs, &geerpc.HealthOption{
+		Interval:      20 * time.Millisecond,
+		Timeout:       time.Second,
+		MaxFailures:   1,
+		EjectDuration: time.Hour,
+	})
+	defer func() { _ = hc.Close() }()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(hc.Alive()) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("NOT_SERVING server was not ejected, alive %v", hc.Alive())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for _, mode := range []SelectMode{RandomSelect, RoundRobinSelect, ConsistentHashSelect} {
+		xc := NewXClient(addrs, mode, nil)
+		xc.SetHealthChecker(hc)
+		for i := 0; i < 30; i++ {
+			key := fmt.Sprintf("user-%d", i)
+			addr, err := xc.pick(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if addr == addrs[1] {
+				t.Fatalf("mode %d picked ejected server for %s", mode, key)
+			}
+			var reply string
+			if err := xc.CallWithKey(key, "Foo.Sum", "a", &reply); err != nil {
+				t.Fatalf("mode %d: %v", mode, err)
+			}
+		}
+		_ = xc.Close()
+	}
+}
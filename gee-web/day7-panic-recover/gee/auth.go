@@ -0,0 +1,57 @@
+package gee
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+)
+
+// AuthUserKey 是认证中间件在 Context 中保存当前用户名的键
+const AuthUserKey = "user"
+
+// Accounts 是用户名到密码的映射
+type Accounts map[string]string
+
+// BasicAuth 返回 HTTP Basic 认证中间件，realm 为 "Authorization Required"
+func BasicAuth(accounts Accounts) HandlerFunc {
+	return BasicAuthForRealm(accounts, "")
+}
+
+// BasicAuthForRealm 返回 HTTP Basic 认证中间件，认证失败时返回 401 和 WWW-Authenticate 头部，
+// 认证成功时用户名保存在 c.Get(AuthUserKey) 中。
+// 密码以 SHA-256 摘要的形式做常数时间比较，比较耗时与用户名是否存在、密码长度都无关
+func BasicAuthForRealm(accounts Accounts, realm string) HandlerFunc {
+	if len(accounts) == 0 {
+		panic("gee: BasicAuth requires at least one account")
+	}
+	if realm == "" {
+		realm = "Authorization Required"
+	}
+	challenge := "Basic realm=" + strconv.Quote(realm)
+	digests := make(map[string][sha256.Size]byte, len(accounts))
+	for user, password := range accounts {
+		if user == "" {
+			panic("gee: BasicAuth user can not be empty")
+		}
+		digests[user] = sha256.Sum256([]byte(password))
+	}
+	// 用户名不存在时与一个不可能匹配的摘要比较，保证耗时一致
+	var missing [sha256.Size]byte
+
+	return func(c *Context) {
+		user, password, ok := c.Req.BasicAuth()
+		expected, found := digests[user]
+		if !found {
+			expected = missing
+		}
+		given := sha256.Sum256([]byte(password))
+		if !ok || subtle.ConstantTimeCompare(given[:], expected[:]) != 1 || !found {
+			c.SetHeader("WWW-Authenticate", challenge)
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Set(AuthUserKey, user)
+		c.Next()
+	}
+}
@@ -0,0 +1,69 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBasicAuth(t *testing.T) {
+	r := New()
+	r.Use(BasicAuthForRealm(Accounts{"admin": "secret", "guest": ""}, "admin area"))
+	r.GET("/me", func(c *Context) {
+		user, _ := c.Get(AuthUserKey)
+		c.String(http.StatusOK, "%v", user)
+	})
+
+	tests := []struct {
+		name           string
+		user, password string
+		noAuth         bool
+		code           int
+		body           string
+	}{
+		{"valid", "admin", "secret", false, http.StatusOK, "admin"},
+		{"empty password", "guest", "", false, http.StatusOK, "guest"},
+		{"wrong password", "admin", "secreT", false, http.StatusUnauthorized, ""},
+		{"unknown user", "root", "secret", false, http.StatusUnauthorized, ""},
+		{"unknown user empty password", "root", "", false, http.StatusUnauthorized, ""},
+		{"missing header", "", "", true, http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/me", nil)
+		if !tt.noAuth {
+			req.SetBasicAuth(tt.user, tt.password)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.code || w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q, want %d %q", tt.name, w.Code, w.Body.String(), tt.code, tt.body)
+		}
+		challenge := w.Header().Get("WWW-Authenticate")
+		if tt.code == http.StatusUnauthorized && challenge != `Basic realm="admin area"` {
+			t.Errorf("%s: WWW-Authenticate = %q", tt.name, challenge)
+		}
+		if tt.code == http.StatusOK && challenge != "" {
+			t.Errorf("%s: unexpected WWW-Authenticate %q", tt.name, challenge)
+		}
+	}
+}
+
+func TestBasicAuthDefaultRealm(t *testing.T) {
+	r := New()
+	r.Use(BasicAuth(Accounts{"admin": "secret"}))
+	r.GET("/", func(c *Context) { c.String(http.StatusOK, "ok") })
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if got := w.Header().Get("WWW-Authenticate"); got != `Basic realm="Authorization Required"` {
+		t.Errorf("WWW-Authenticate = %q", got)
+	}
+}
+
+func TestBasicAuthRequiresAccounts(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for empty accounts")
+		}
+	}()
+	BasicAuth(nil)
+}
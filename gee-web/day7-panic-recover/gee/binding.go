@@ -0,0 +1,251 @@
+package gee
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+/*
+请求绑定：把请求中的数据解码到结构体中，解码完成后按 binding 标签校验
+  - JSON / XML 请求体使用 encoding/json、encoding/xml 解码，字段名由 json、xml 标签决定
+  - 查询参数、表单使用 form 标签，路由参数使用 uri 标签，没有标签时使用字段名，标签为 "-" 时忽略该字段
+*/
+
+const (
+	MIMEJSON              = "application/json"
+	MIMEXML               = "application/xml"
+	MIMEXML2              = "text/xml"
+	MIMEPOSTForm          = "application/x-www-form-urlencoded"
+	MIMEMultipartPOSTForm = "multipart/form-data"
+)
+
+// defaultMultipartMemory 是 Engine.MaxMultipartMemory 的默认值
+const defaultMultipartMemory = 32 << 20
+
+// Bind 与 ShouldBind 相同，但绑定或校验失败时会记录错误、终止后续处理函数并返回 400，
+// 请求体超过 BodyLimit 的限制时返回 413，binding 标签本身有误时返回 500
+func (c *Context) Bind(obj interface{}) error {
+	err := c.ShouldBind(obj)
+	if err != nil {
+		c.Abort()
+		var ve ValidationErrors
+		var tooLarge *http.MaxBytesError
+		var invalidRule *InvalidRuleError
+		if errors.As(err, &invalidRule) {
+			c.Error(err).SetType(ErrorTypePrivate)
+			c.JSON(http.StatusInternalServerError, H{"message": http.StatusText(http.StatusInternalServerError)})
+			return err
+		}
+		c.Error(err).SetType(ErrorTypeBind)
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, H{"message": http.StatusText(http.StatusRequestEntityTooLarge)})
+		} else if errors.As(err, &ve) {
+			c.JSON(http.StatusBadRequest, H{"message": "validation failed", "errors": ve})
+		} else {
+			c.JSON(http.StatusBadRequest, H{"message": err.Error()})
+		}
+	}
+	return err
+}
+
+// ShouldBind 根据请求方法和 Content-Type 选择解码方式：
+// GET/DELETE 请求绑定查询参数，JSON、XML 请求体分别按 JSON、XML 解码，其余按表单解码
+func (c *Context) ShouldBind(obj interface{}) error {
+	if c.Method == http.MethodGet || c.Method == http.MethodDelete {
+		return c.ShouldBindQuery(obj)
+	}
+	switch c.ContentType() {
+	case MIMEJSON:
+		return c.ShouldBindJSON(obj)
+	case MIMEXML, MIMEXML2:
+		return c.ShouldBindXML(obj)
+	default:
+		return c.ShouldBindForm(obj)
+	}
+}
+
+// ShouldBindJSON 把 JSON 请求体解码到 obj 并校验
+func (c *Context) ShouldBindJSON(obj interface{}) error {
+	if c.Req.Body == nil {
+		return errors.New("gee: empty request body")
+	}
+	if err := json.NewDecoder(c.Req.Body).Decode(obj); err != nil {
+		return err
+	}
+	return Validate(obj)
+}
+
+// ShouldBindXML 把 XML 请求体解码到 obj 并校验
+func (c *Context) ShouldBindXML(obj interface{}) error {
+	if c.Req.Body == nil {
+		return errors.New("gee: empty request body")
+	}
+	if err := xml.NewDecoder(c.Req.Body).Decode(obj); err != nil {
+		return err
+	}
+	return Validate(obj)
+}
+
+// ShouldBindQuery 按 form 标签把查询参数绑定到 obj 并校验
+func (c *Context) ShouldBindQuery(obj interface{}) error {
+	if err := mapForm(obj, c.Req.URL.Query(), "form"); err != nil {
+		return err
+	}
+	return Validate(obj)
+}
+
+// ShouldBindForm 按 form 标签把表单（包括查询参数）绑定到 obj 并校验
+func (c *Context) ShouldBindForm(obj interface{}) error {
+	if err := c.parseForm(); err != nil {
+		return err
+	}
+	if err := mapForm(obj, c.Req.Form, "form"); err != nil {
+		return err
+	}
+	return Validate(obj)
+}
+
+// ShouldBindURI 按 uri 标签把路由参数绑定到 obj 并校验
+func (c *Context) ShouldBindURI(obj interface{}) error {
+	values := make(map[string][]string, len(c.Params))
+	for _, p := range c.Params {
+		values[p.Key] = append(values[p.Key], p.Value)
+	}
+	if err := mapForm(obj, values, "uri"); err != nil {
+		return err
+	}
+	return Validate(obj)
+}
+
+// ContentType 返回请求的 Content-Type，不包含 charset 等参数
+func (c *Context) ContentType() string {
+	ct, _, _ := mime.ParseMediaType(c.Req.Header.Get("Content-Type"))
+	return ct
+}
+
+// mapForm 把 values 按 tag 指定的标签映射到 obj 指向的结构体
+func mapForm(obj interface{}, values map[string][]string, tag string) error {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.New("gee: binding requires a non-nil pointer to struct")
+	}
+	return mapStruct(v.Elem(), values, tag)
+}
+
+func mapStruct(v reflect.Value, values map[string][]string, tag string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Tag.Get(tag)
+		if name == "-" {
+			continue
+		}
+		fv := v.Field(i)
+		// 没有标签的结构体字段（包括匿名嵌入）递归绑定
+		if name == "" && field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Time{}) {
+			if err := mapStruct(fv, values, tag); err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		vals, ok := values[name]
+		if !ok || len(vals) == 0 {
+			continue
+		}
+		if err := setField(fv, vals); err != nil {
+			return fmt.Errorf("gee: binding field %s: %w", field.Name, err)
+		}
+	}
+	return nil
+}
+
+// setField 把字符串形式的值转换为字段的类型，切片字段接收全部值，其余字段取第一个值
+func setField(fv reflect.Value, vals []string) error {
+	switch fv.Kind() {
+	case reflect.Slice:
+		slice := reflect.MakeSlice(fv.Type(), len(vals), len(vals))
+		for i, val := range vals {
+			if err := setValue(slice.Index(i), val); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	case reflect.Ptr:
+		ptr := reflect.New(fv.Type().Elem())
+		if err := setField(ptr.Elem(), vals); err != nil {
+			return err
+		}
+		fv.Set(ptr)
+		return nil
+	default:
+		return setValue(fv, vals[0])
+	}
+}
+
+func setValue(fv reflect.Value, val string) error {
+	if fv.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(val)
+		if err == nil {
+			fv.SetInt(int64(d))
+		}
+		return err
+	}
+	if fv.Type() == reflect.TypeOf(time.Time{}) {
+		tm, err := time.Parse(time.RFC3339, val)
+		if err == nil {
+			fv.Set(reflect.ValueOf(tm))
+		}
+		return err
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(val, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(val, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(val, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(n)
+	case reflect.Ptr:
+		ptr := reflect.New(fv.Type().Elem())
+		if err := setValue(ptr.Elem(), val); err != nil {
+			return err
+		}
+		fv.Set(ptr)
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package gee
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gee/internal/geetest"
+)
+
+type testAddress struct {
+	City string `json:"city" form:"city" binding:"required"`
+	Zip  string `json:"zip" form:"zip" binding:"len=6"`
+}
+
+type testUser struct {
+	Name    string      `json:"name" form:"name" uri:"name" binding:"required,min=3,max=8"`
+	Age     int         `json:"age" form:"age" binding:"min=18"`
+	Role    string      `json:"role" form:"role" binding:"oneof=admin guest"`
+	Email   string      `json:"email" form:"email" binding:"email"`
+	Tags    []string    `json:"tags" form:"tag"`
+	Code    string      `json:"code" form:"code" binding:"regexp=^[a-z]{1,3}$"`
+	Address testAddress `json:"address"`
+}
+
+func bindRequest(req *http.Request, bind func(c *Context) error) error {
+	var err error
+	r := New()
+	r.Any("/users/:name", func(c *Context) {
+		err = bind(c)
+	})
+	geetest.Serve(r, req)
+	return err
+}
+
+func TestShouldBind(t *testing.T) {
+	want := testUser{Name: "geektutu", Age: 20, Role: "admin", Email: "a@b.cn", Tags: []string{"x", "y"}, Address: testAddress{City: "sh"}}
+	body := `{"name":"geektutu","age":20,"role":"admin","email":"a@b.cn","tags":["x","y"],"address":{"city":"sh"}}`
+	form := url.Values{"name": {"geektutu"}, "age": {"20"}, "role": {"admin"}, "email": {"a@b.cn"}, "tag": {"x", "y"}, "city": {"sh"}}
+
+	jsonReq := httptest.NewRequest("POST", "/users/x", strings.NewReader(body))
+	jsonReq.Header.Set("Content-Type", "application/json; charset=utf-8")
+	formReq := httptest.NewRequest("POST", "/users/x", strings.NewReader(form.Encode()))
+	formReq.Header.Set("Content-Type", MIMEPOSTForm)
+	queryReq := httptest.NewRequest("GET", "/users/x?"+form.Encode(), nil)
+
+	for name, req := range map[string]*http.Request{"json": jsonReq, "form": formReq, "query": queryReq} {
+		var u testUser
+		if err := bindRequest(req, func(c *Context) error { return c.ShouldBind(&u) }); err != nil {
+			t.Fatalf("%s: unexpected error %v", name, err)
+		}
+		if !reflect.DeepEqual(u, want) {
+			t.Fatalf("%s: got %+v, want %+v", name, u, want)
+		}
+	}
+}
+
+func TestShouldBindURI(t *testing.T) {
+	var u struct {
+		Name string `uri:"name" binding:"required"`
+	}
+	err := bindRequest(httptest.NewRequest("GET", "/users/geektutu", nil), func(c *Context) error { return c.ShouldBindURI(&u) })
+	if err != nil || u.Name != "geektutu" {
+		t.Fatalf("got %q, %v", u.Name, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	u := testUser{Name: "ab", Age: 3, Role: "root", Email: "nope", Code: "a,b", Address: testAddress{Zip: "123"}}
+	err := Validate(&u)
+	var ve ValidationErrors
+	if !errors.As(err, &ve) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+	var got []string
+	for _, fe := range ve {
+		got = append(got, fe.Field+":"+fe.Tag)
+	}
+	want := []string{"Name:min", "Age:min", "Role:oneof", "Email:email", "Code:regexp", "Address.City:required", "Address.Zip:len"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if err := Validate(&testUser{Name: "geektutu", Age: 18, Address: testAddress{City: "sh"}}); err != nil {
+		t.Fatalf("empty optional strings should pass, got %v", err)
+	}
+	// 数字的零值同样按规则校验
+	err = Validate(&testUser{Name: "geektutu", Address: testAddress{City: "sh"}})
+	if !errors.As(err, &ve) || len(ve) != 1 || ve[0].Field != "Age" || ve[0].Tag != "min" {
+		t.Fatalf("zero Age should fail min=18, got %v", err)
+	}
+}
+
+func TestValidateNumericZero(t *testing.T) {
+	type limits struct {
+		Count   int      `binding:"min=1"`
+		Score   float64  `binding:"gte=0.5,lte=1"`
+		Retries *int     `binding:"min=1"`
+		Level   uint     `binding:"lte=3"`
+		Tags    []string `binding:"min=1"`
+	}
+	err := Validate(&limits{Level: 4})
+	var ve ValidationErrors
+	if !errors.As(err, &ve) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+	var got []string
+	for _, fe := range ve {
+		got = append(got, fe.Field+":"+fe.Tag)
+	}
+	// nil 指针与空切片视为未设置，不做校验
+	want := []string{"Count:min", "Score:gte", "Level:lte"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	zero := 0
+	if err := Validate(&limits{Count: 1, Score: 1, Retries: &zero}); err == nil || !strings.Contains(err.Error(), "Retries") {
+		t.Fatalf("pointer to zero should be validated, got %v", err)
+	}
+	if err := Validate(&limits{Count: 1, Score: 0.5}); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestValidateMalformedRule(t *testing.T) {
+	tests := []struct {
+		obj  interface{}
+		rule string
+	}{
+		{&struct {
+			N int `binding:"gt=0"`
+		}{N: 1}, "gt=0"},
+		{&struct {
+			S string `binding:"min=abc"`
+		}{}, "min=abc"},
+		{&struct {
+			S string `binding:"regexp=("`
+		}{S: "x"}, "regexp=("},
+		{&struct {
+			B bool `binding:"min=1"`
+		}{B: true}, "min=1"},
+	}
+	for _, tt := range tests {
+		err := Validate(tt.obj)
+		var ire *InvalidRuleError
+		if !errors.As(err, &ire) || ire.Rule != tt.rule {
+			t.Errorf("%s: expected InvalidRuleError, got %v", tt.rule, err)
+		}
+	}
+
+	r := New()
+	r.POST("/bad", func(c *Context) {
+		var form struct {
+			Age int `form:"age" binding:"gt=0"`
+		}
+		c.Bind(&form)
+	})
+	req := httptest.NewRequest("POST", "/bad", strings.NewReader("age=1"))
+	req.Header.Set("Content-Type", MIMEPOSTForm)
+	w := geetest.Serve(r, req)
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "gt=0") {
+		t.Fatalf("expected 500 without leaking the rule, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestBindAborts(t *testing.T) {
+	r := New()
+	called := false
+	r.POST("/users", func(c *Context) {
+		var u testUser
+		if c.Bind(&u) == nil {
+			called = true
+		}
+	}, func(c *Context) {
+		called = true
+	})
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":"x","age":20}`))
+	req.Header.Set("Content-Type", MIMEJSON)
+	w := geetest.Serve(r, req)
+	if w.Code != http.StatusBadRequest || called {
+		t.Fatalf("expected 400 and abort, got %d, called=%v", w.Code, called)
+	}
+	var resp struct {
+		Errors []FieldError `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || len(resp.Errors) != 2 {
+		t.Fatalf("unexpected body %s", w.Body.String())
+	}
+}
@@ -0,0 +1,48 @@
+package gee
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// BodyLimit 返回限制请求体大小的中间件，超过 limit 字节时返回 413
+//   - Content-Length 已经超过限制时直接拒绝，不读取请求体
+//   - 没有 Content-Length（分块传输）时，读取超过限制后请求体返回 *http.MaxBytesError，
+//     处理函数没有写出响应时由中间件返回 413，Bind 遇到该错误时同样返回 413
+func BodyLimit(limit int64) HandlerFunc {
+	if limit <= 0 {
+		panic("gee: BodyLimit requires a positive limit")
+	}
+	return func(c *Context) {
+		if c.Req.ContentLength > limit {
+			c.Fail(http.StatusRequestEntityTooLarge, http.StatusText(http.StatusRequestEntityTooLarge))
+			return
+		}
+		if c.Req.Body == nil || c.Req.Body == http.NoBody {
+			c.Next()
+			return
+		}
+		body := &limitedBody{ReadCloser: http.MaxBytesReader(c.Writer, c.Req.Body, limit)}
+		c.Req.Body = body
+		c.Next()
+		if body.exceeded && !c.Writer.Written() {
+			c.Fail(http.StatusRequestEntityTooLarge, http.StatusText(http.StatusRequestEntityTooLarge))
+		}
+	}
+}
+
+// limitedBody 记录请求体是否读取超过了限制
+type limitedBody struct {
+	io.ReadCloser
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		b.exceeded = true
+	}
+	return n, err
+}
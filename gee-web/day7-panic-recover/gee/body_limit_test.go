@@ -0,0 +1,74 @@
+package gee
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimit(t *testing.T) {
+	r := New()
+	r.Use(BodyLimit(16))
+	r.POST("/read", func(c *Context) {
+		data, err := io.ReadAll(c.Req.Body)
+		if err != nil {
+			return // 由 BodyLimit 返回 413
+		}
+		c.String(http.StatusOK, "%d", len(data))
+	})
+	r.POST("/bind", func(c *Context) {
+		var obj struct {
+			Name string `json:"name"`
+		}
+		if c.Bind(&obj) == nil {
+			c.String(http.StatusOK, obj.Name)
+		}
+	})
+	r.GET("/empty", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	tests := []struct {
+		name, path, body string
+		chunked          bool
+		code             int
+		resp             string
+	}{
+		{"within limit", "/read", "0123456789", false, http.StatusOK, "10"},
+		{"exactly limit", "/read", strings.Repeat("x", 16), false, http.StatusOK, "16"},
+		{"content length too large", "/read", strings.Repeat("x", 17), false, http.StatusRequestEntityTooLarge, "Request Entity Too Large"},
+		{"chunked within limit", "/read", "0123456789", true, http.StatusOK, "10"},
+		{"chunked too large", "/read", strings.Repeat("x", 100), true, http.StatusRequestEntityTooLarge, "Request Entity Too Large"},
+		{"bind too large", "/bind", `{"name":"` + strings.Repeat("x", 100) + `"}`, true, http.StatusRequestEntityTooLarge, "Request Entity Too Large"},
+		{"bind within limit", "/bind", `{"name":"gee"}`, true, http.StatusOK, "gee"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", MIMEJSON)
+		if tt.chunked {
+			req.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.resp) {
+			t.Errorf("%s: got %d %q, want %d %q", tt.name, w.Code, w.Body.String(), tt.code, tt.resp)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/empty", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("request without body: got %d", w.Code)
+	}
+}
+
+func TestBodyLimitRequiresPositiveLimit(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for non-positive limit")
+		}
+	}()
+	BodyLimit(0)
+}
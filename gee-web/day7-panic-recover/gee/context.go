@@ -1,23 +1,39 @@
 package gee
 
 import (
+    "bytes"
     "encoding/json"
     "fmt"
+    "math"
+    "net"
     "net/http"
+    "net/url"
+    "strings"
+    "sync"
 )
 
 type H map[string]interface{}
 
 type Context struct {
     //origin objects
-    Writer http.ResponseWriter
+    Writer ResponseWriter
     Req *http.Request
     //request info
     Path string
     Method string
-    Params map[string]string //参数映射
+    Params Params //路由参数，按在路由中出现的顺序排列
+    queryCache url.Values //解析后的查询参数，第一次调用 Query 系列方法时填充
+    formParsed bool //请求体中的表单是否已解析，每个请求只解析一次
+    formErr error //解析表单时的错误
     //reponse info
-    StatusCode int
+    StatusCode int //通过 c.Status 设置的状态码，实际写出的状态码以 c.Writer.Status() 为准
+    writermem responseWriter //Writer 指向它，随 Context 一起复用
+
+    //通过 c.Error 记录的错误
+    Errors errorMsgs
+    //在中间件与处理函数之间传递数据，例如认证中间件保存的当前用户，通过 Set/Get 访问
+    Keys map[string]interface{}
+    mu sync.RWMutex //protect Keys
 
     //middleware
     handlers []HandlerFunc
@@ -27,14 +43,25 @@ type Context struct {
 }
 
 
-func newContext(w http.ResponseWriter, req *http.Request) *Context {
-    return &Context{
-        Writer: w,
-        Req: req,
-        Path: req.URL.Path,
-        Method: req.Method,
-        index: -1,
-    }
+//Context 由 Engine 通过 sync.Pool 复用，每个请求开始前调用 reset 清空上一次请求留下的状态
+//因此处理函数返回后不应再持有 Context
+func (c *Context) reset(w http.ResponseWriter, req *http.Request) {
+    c.writermem.reset(w)
+    c.Writer = &c.writermem
+    c.Req = req
+    c.Path = req.URL.Path
+    c.Method = req.Method
+    c.Params = c.Params[:0]
+    c.queryCache = nil
+    c.formParsed = false
+    c.formErr = nil
+    c.StatusCode = 0
+    c.Errors = c.Errors[:0]
+    c.mu.Lock()
+    c.Keys = nil
+    c.mu.Unlock()
+    c.handlers = nil
+    c.index = -1
 }
 
 //该 Next() 方法的目的是在中间件链中继续执行下一个中间件
@@ -48,26 +75,44 @@ func (c *Context) Next() {
 
 }
 
+//Abort 之后 index 的值，远大于任何处理链的长度，用于区分终止与处理链正常执行完毕
+const abortIndex = math.MaxInt / 2
+
+//终止处理链：当前处理函数返回后，后续的中间件和处理函数都不再执行，已经在执行的中间件不受影响
+func (c *Context) Abort() {
+    c.index = abortIndex
+}
+
+//判断处理链是否已经被终止
+func (c *Context) IsAborted() bool {
+    return c.index >= abortIndex
+}
+
+//终止处理链并设置状态码，响应头在处理链结束时写出
+func (c *Context) AbortWithStatus(code int) {
+    c.Status(code)
+    c.Abort()
+}
+
 //该 Fail() 方法的作用是终止当前请求的处理过程，并返回一个带有指定状态码和错误信息的 JSON 响应
 func (c *Context) Fail(code int, err string) {
-    c.index = len(c.handlers)
+    c.Abort()
     c.JSON(code, H{"message": err})
 }
 
 //获取路由中的参数值
 func (c *Context) Param(key string) string {
-	value, _ := c.Params[key]
-	return value
-}
-
-//该方法用于获取HTTP POST请求中指定键（key）对应的表单数据值（value）
-func (c *Context) PostForm(key string) string {
-    return c.Req.FormValue(key)
+    return c.Params.ByName(key)
 }
 
-//该方法用于获取HTTP请求URL中指定键（key）对应的查询参数值（value）。
-func (c *Context) Query(key string) string {
-    return c.Req.URL.Query().Get(key)
+//返回客户端的 IP，即 RemoteAddr 中的主机部分
+//不信任 X-Forwarded-For 等可以被客户端伪造的头部，位于反向代理之后时需要由代理或中间件改写 RemoteAddr
+func (c *Context) ClientIP() string {
+    host, _, err := net.SplitHostPort(strings.TrimSpace(c.Req.RemoteAddr))
+    if err != nil {
+        return c.Req.RemoteAddr
+    }
+    return host
 }
 
 func (c *Context) Status(code int) {
@@ -82,14 +127,14 @@ func (c *Context) SetHeader(key string, value string) {
 
 //返回文本格式的HTTP响应
 func (c *Context) String(code int, format string, values ...interface{}) {
-    c.SetHeader("Content-Type", "text/plain")
+    c.SetHeader("Content-Type", "text/plain; charset=utf-8")
     c.Status(code)
     //将格式化后的字符串作为HTTP响应的内容写入到响应写入器中，以便将其发送给客户端
     c.Writer.Write([]byte(fmt.Sprintf(format, values...)))
 }
 
 func (c *Context) JSON(code int, obj interface{}) {
-    c.SetHeader("Context-Type", "application/json")
+    c.SetHeader("Content-Type", "application/json; charset=utf-8")
     c.Status(code)
     //创建一个json编码器，用于将json数据写入c.Writer中
     encoder := json.NewEncoder(c.Writer)
@@ -109,12 +154,15 @@ func (c *Context) Data(code int, data []byte) {
 }
 
 
+//先渲染到缓冲区中，模板执行出错时返回干净的 500，而不是已经写出一半的 HTML
 func (c *Context) HTML(code int, name string, data interface{}) {
-    c.SetHeader("Context-Type", "text/html")
-    c.Status(code)
-    //执行模板渲染操作。
-    //它使用c.engine.htmlTemplates作为HTML模板引擎来渲染指定的模板name，并将data作为模板的数据
-    if err := c.engine.htmlTemplates.ExecuteTemplate(c.Writer, name, data); err != nil {
-		c.Fail(500, err.Error())
-	}
+    buf := htmlBufferPool.Get().(*bytes.Buffer)
+    buf.Reset()
+    defer htmlBufferPool.Put(buf)
+    if err := c.engine.renderHTML(buf, name, data); err != nil {
+        c.Error(err).SetType(ErrorTypeRender)
+        c.Fail(http.StatusInternalServerError, err.Error())
+        return
+    }
+    c.writeBody(code, "text/html; charset=utf-8", buf.Bytes())
 }
@@ -0,0 +1,176 @@
+// Package cors 提供跨域资源共享（CORS）中间件
+//
+// 预检请求（OPTIONS + Access-Control-Request-Method）由中间件直接应答 204 并终止处理链。
+// 未注册 OPTIONS 路由时，预检请求只会经过全局中间件，因此应在 Engine 上注册：
+//
+//	r := gee.New()
+//	r.Use(cors.New(cors.Config{
+//		AllowOrigins:     []string{"https://example.com", "https://*.example.com"},
+//		AllowCredentials: true,
+//		MaxAge:           12 * time.Hour,
+//	}))
+package cors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gee"
+)
+
+// Config 是 CORS 中间件的配置
+type Config struct {
+	// AllowOrigins 是允许的来源，支持精确匹配、包含一个 * 的通配（如 https://*.example.com），"*" 允许所有来源
+	AllowOrigins []string
+	// AllowOriginFunc 不为 nil 时，AllowOrigins 未匹配的来源再交给它判断
+	AllowOriginFunc func(origin string) bool
+	// AllowMethods 是预检请求中允许的方法，默认为 GET、POST、PUT、PATCH、DELETE、HEAD
+	AllowMethods []string
+	// AllowHeaders 是预检请求中允许的请求头部，为空时允许预检请求中列出的所有头部
+	AllowHeaders []string
+	// ExposeHeaders 是允许浏览器读取的响应头部
+	ExposeHeaders []string
+	// AllowCredentials 允许请求携带 Cookie 等凭据，Access-Control-Allow-Origin 返回具体的来源
+	// 不能与 AllowOrigins 中的 "*" 同时使用，否则任何网站都能以用户的身份发起请求
+	AllowCredentials bool
+	// MaxAge 是预检结果的缓存时间，为 0 时不设置
+	MaxAge time.Duration
+}
+
+var defaultMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodHead,
+}
+
+// Default 返回允许所有来源、不允许凭据的 CORS 中间件
+func Default() gee.HandlerFunc {
+	return New(Config{AllowOrigins: []string{"*"}})
+}
+
+// New 返回按 config 处理跨域请求的中间件
+// 没有配置任何允许的来源，或者允许所有来源（"*"）的同时允许凭据时 panic
+func New(config Config) gee.HandlerFunc {
+	if len(config.AllowOrigins) == 0 && config.AllowOriginFunc == nil {
+		panic("cors: at least one allowed origin or AllowOriginFunc is required")
+	}
+	if config.AllowCredentials {
+		for _, origin := range config.AllowOrigins {
+			if origin == "*" {
+				panic("cors: AllowOrigins \"*\" can not be used with AllowCredentials, list the trusted origins instead")
+			}
+		}
+	}
+	c := newCors(config)
+	return c.handle
+}
+
+type cors struct {
+	config        Config
+	allowAll      bool
+	exact         map[string]struct{}
+	wildcards     [][2]string // 通配来源的前缀与后缀
+	allowMethods  string
+	allowHeaders  string
+	exposeHeaders string
+	maxAge        string
+}
+
+func newCors(config Config) *cors {
+	c := &cors{config: config, exact: make(map[string]struct{})}
+	for _, origin := range config.AllowOrigins {
+		origin = strings.ToLower(origin)
+		switch {
+		case origin == "*":
+			c.allowAll = true
+		case strings.Count(origin, "*") == 1:
+			i := strings.IndexByte(origin, '*')
+			c.wildcards = append(c.wildcards, [2]string{origin[:i], origin[i+1:]})
+		case strings.Contains(origin, "*"):
+			panic("cors: only one wildcard is allowed in origin " + origin)
+		default:
+			c.exact[origin] = struct{}{}
+		}
+	}
+	methods := config.AllowMethods
+	if len(methods) == 0 {
+		methods = defaultMethods
+	}
+	c.allowMethods = strings.ToUpper(strings.Join(methods, ", "))
+	c.allowHeaders = strings.Join(config.AllowHeaders, ", ")
+	c.exposeHeaders = strings.Join(config.ExposeHeaders, ", ")
+	if config.MaxAge > 0 {
+		c.maxAge = strconv.FormatInt(int64(config.MaxAge/time.Second), 10)
+	}
+	return c
+}
+
+func (cs *cors) allowed(origin string) bool {
+	if cs.allowAll {
+		return true
+	}
+	lower := strings.ToLower(origin)
+	if _, ok := cs.exact[lower]; ok {
+		return true
+	}
+	for _, w := range cs.wildcards {
+		if len(lower) >= len(w[0])+len(w[1]) && strings.HasPrefix(lower, w[0]) && strings.HasSuffix(lower, w[1]) {
+			return true
+		}
+	}
+	return cs.config.AllowOriginFunc != nil && cs.config.AllowOriginFunc(origin)
+}
+
+func (cs *cors) handle(c *gee.Context) {
+	origin := c.Req.Header.Get("Origin")
+	if origin == "" {
+		// 不是跨域请求
+		c.Next()
+		return
+	}
+	header := c.Writer.Header()
+	// 响应内容随 Origin 变化，告知缓存
+	if !cs.allowAll {
+		header.Add("Vary", "Origin")
+	}
+	preflight := c.Method == http.MethodOptions && c.Req.Header.Get("Access-Control-Request-Method") != ""
+	if !cs.allowed(origin) {
+		if preflight {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		// 普通请求照常处理，不带 CORS 头部时浏览器会拒绝脚本读取响应
+		c.Next()
+		return
+	}
+
+	if cs.allowAll {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+	if cs.config.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	if !preflight {
+		if cs.exposeHeaders != "" {
+			header.Set("Access-Control-Expose-Headers", cs.exposeHeaders)
+		}
+		c.Next()
+		return
+	}
+
+	header.Add("Vary", "Access-Control-Request-Method")
+	header.Add("Vary", "Access-Control-Request-Headers")
+	header.Set("Access-Control-Allow-Methods", cs.allowMethods)
+	if cs.allowHeaders != "" {
+		header.Set("Access-Control-Allow-Headers", cs.allowHeaders)
+	} else if requested := c.Req.Header.Get("Access-Control-Request-Headers"); requested != "" {
+		header.Set("Access-Control-Allow-Headers", requested)
+	}
+	if cs.maxAge != "" {
+		header.Set("Access-Control-Max-Age", cs.maxAge)
+	}
+	c.AbortWithStatus(http.StatusNoContent)
+}
@@ -0,0 +1,94 @@
+package cors
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"gee"
+	"gee/internal/geetest"
+)
+
+func newTestEngine(config Config) *gee.Engine {
+	r := gee.New()
+	r.Use(New(config))
+	r.GET("/data", func(c *gee.Context) {
+		c.String(http.StatusOK, "data")
+	})
+	return r
+}
+
+func TestOrigins(t *testing.T) {
+	r := newTestEngine(Config{
+		AllowOrigins:    []string{"https://example.com", "https://*.example.org"},
+		AllowOriginFunc: func(origin string) bool { return origin == "http://localhost:3000" },
+		ExposeHeaders:   []string{"X-Total"},
+	})
+	tests := []struct {
+		origin, allowOrigin string
+	}{
+		{"https://example.com", "https://example.com"},
+		{"https://api.example.org", "https://api.example.org"},
+		{"http://localhost:3000", "http://localhost:3000"},
+		{"https://example.org.evil.com", ""},
+		{"https://evil.com", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		w := geetest.PerformRequest(r, "GET", "/data", "Origin", tt.origin)
+		if w.Code != http.StatusOK || w.Body.String() != "data" || w.Header().Get("Access-Control-Allow-Origin") != tt.allowOrigin {
+			t.Errorf("origin %q: got %d %q allow=%q", tt.origin, w.Code, w.Body.String(), w.Header().Get("Access-Control-Allow-Origin"))
+		}
+		if tt.allowOrigin != "" && w.Header().Get("Access-Control-Expose-Headers") != "X-Total" {
+			t.Errorf("origin %q: missing expose headers", tt.origin)
+		}
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	r := newTestEngine(Config{
+		AllowOrigins:     []string{"https://example.com"},
+		AllowMethods:     []string{"GET", "PUT"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	})
+	w := geetest.PerformRequest(r, "OPTIONS", "/data", "Origin", "https://example.com",
+		"Access-Control-Request-Method", "PUT", "Access-Control-Request-Headers", "X-Token")
+	want := map[string]string{
+		"Access-Control-Allow-Origin":      "https://example.com",
+		"Access-Control-Allow-Methods":     "GET, PUT",
+		"Access-Control-Allow-Headers":     "X-Token",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Max-Age":           "3600",
+	}
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Fatalf("preflight should short-circuit with 204, got %d %q", w.Code, w.Body.String())
+	}
+	for key, value := range want {
+		if got := w.Header().Get(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+	if w := geetest.PerformRequest(r, "OPTIONS", "/data", "Origin", "https://evil.com", "Access-Control-Request-Method", "PUT"); w.Code != http.StatusForbidden {
+		t.Fatalf("preflight from disallowed origin should be 403, got %d", w.Code)
+	}
+}
+
+func TestDefault(t *testing.T) {
+	r := gee.New()
+	r.Use(Default())
+	r.GET("/data", func(c *gee.Context) {})
+	w := geetest.PerformRequest(r, "GET", "/data", "Origin", "https://any.com")
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" || w.Header().Get("Vary") != "" {
+		t.Fatalf("unexpected headers %v", w.Header())
+	}
+}
+
+func TestWildcardWithCredentialsPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for AllowOrigins \"*\" with AllowCredentials")
+		}
+	}()
+	New(Config{AllowOrigins: []string{"https://example.com", "*"}, AllowCredentials: true})
+}
@@ -0,0 +1,201 @@
+package gee
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+/*
+集中的错误处理：处理函数通过 c.Error 把错误记录在 Context 上，而不是各自拼接错误响应，
+再由 ErrorHandler 中间件在处理链结束后统一渲染为 RFC 7807 problem details 格式的 JSON。
+错误分为公开与私有两类：公开错误的信息会返回给客户端，私有错误只用于日志等内部用途。
+*/
+
+// ErrorType 是错误的类型，可以按位组合
+type ErrorType uint64
+
+const (
+	// ErrorTypePrivate 私有错误，信息不会返回给客户端
+	ErrorTypePrivate ErrorType = 1 << iota
+	// ErrorTypePublic 公开错误，信息会返回给客户端
+	ErrorTypePublic
+	// ErrorTypeBind 请求绑定或校验失败，信息会返回给客户端
+	ErrorTypeBind
+	// ErrorTypeRender 渲染响应失败
+	ErrorTypeRender
+
+	// ErrorTypeAny 匹配任意类型
+	ErrorTypeAny ErrorType = 1<<64 - 1
+)
+
+// MIMEProblemJSON 是 problem details 响应的 Content-Type
+const MIMEProblemJSON = "application/problem+json"
+
+// Error 是记录在 Context 上的错误
+type Error struct {
+	Err  error
+	Type ErrorType
+	Meta interface{} // 附加信息，公开错误的 Meta 会随错误一起返回给客户端
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// SetType 设置错误的类型
+func (e *Error) SetType(flags ErrorType) *Error {
+	e.Type = flags
+	return e
+}
+
+// SetMeta 设置错误的附加信息
+func (e *Error) SetMeta(meta interface{}) *Error {
+	e.Meta = meta
+	return e
+}
+
+// IsType 判断错误是否属于 flags 中的某个类型
+func (e *Error) IsType(flags ErrorType) bool {
+	return e.Type&flags > 0
+}
+
+// IsPublic 判断错误信息是否可以返回给客户端
+func (e *Error) IsPublic() bool {
+	return e.IsType(ErrorTypePublic | ErrorTypeBind)
+}
+
+// JSON 返回错误的 JSON 表示：错误信息，以及 Meta（为 H 时合并到结果中）
+func (e *Error) JSON() interface{} {
+	result := H{"message": e.Error()}
+	switch meta := e.Meta.(type) {
+	case nil:
+	case H:
+		for key, value := range meta {
+			if _, ok := result[key]; !ok {
+				result[key] = value
+			}
+		}
+	default:
+		result["meta"] = meta
+	}
+	return result
+}
+
+// errorMsgs 是一次请求中记录的全部错误，按记录的顺序排列
+type errorMsgs []*Error
+
+// ByType 返回属于 flags 中某个类型的错误
+func (a errorMsgs) ByType(flags ErrorType) errorMsgs {
+	if len(a) == 0 {
+		return nil
+	}
+	if flags == ErrorTypeAny {
+		return a
+	}
+	var result errorMsgs
+	for _, err := range a {
+		if err.IsType(flags) {
+			result = append(result, err)
+		}
+	}
+	return result
+}
+
+// Last 返回最后一个错误，没有错误时返回 nil
+func (a errorMsgs) Last() *Error {
+	if len(a) == 0 {
+		return nil
+	}
+	return a[len(a)-1]
+}
+
+// Errors 返回全部错误的信息
+func (a errorMsgs) Errors() []string {
+	if len(a) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(a))
+	for i, err := range a {
+		msgs[i] = err.Error()
+	}
+	return msgs
+}
+
+func (a errorMsgs) String() string {
+	var buf strings.Builder
+	for i, err := range a {
+		fmt.Fprintf(&buf, "Error #%02d: %s\n", i+1, err.Err)
+		if err.Meta != nil {
+			fmt.Fprintf(&buf, "     Meta: %v\n", err.Meta)
+		}
+	}
+	return buf.String()
+}
+
+// Error 把 err 记录到 Context 上，返回的 *Error 可以继续设置类型与附加信息
+// err 已经是 *Error 时直接记录，否则记录为私有错误
+func (c *Context) Error(err error) *Error {
+	if err == nil {
+		panic("gee: err is nil")
+	}
+	e, ok := err.(*Error)
+	if !ok {
+		e = &Error{Err: err, Type: ErrorTypePrivate}
+	}
+	c.Errors = append(c.Errors, e)
+	return e
+}
+
+// AbortWithError 设置状态码、终止后续的处理函数并记录 err
+// 响应体由 ErrorHandler 等中间件根据记录的错误生成
+func (c *Context) AbortWithError(code int, err error) *Error {
+	c.Status(code)
+	c.Abort()
+	return c.Error(err)
+}
+
+// ErrorHandler 在处理链结束后，把记录的错误渲染为 problem details：
+//
+//	{"type":"about:blank","title":"Bad Request","status":400,"detail":"...","errors":[{"message":"..."}]}
+//
+// 状态码为已设置的状态码，未设置错误状态码时使用 500；
+// detail 与 errors 只包含公开错误，私有错误的信息不会返回给客户端。
+// 处理函数已经写出响应时不做任何处理
+func ErrorHandler() HandlerFunc {
+	return func(c *Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		status := c.Writer.Status()
+		if status < http.StatusBadRequest {
+			status = http.StatusInternalServerError
+		}
+		problem := H{
+			"type":   "about:blank",
+			"title":  http.StatusText(status),
+			"status": status,
+		}
+		public := c.Errors.ByType(ErrorTypePublic | ErrorTypeBind)
+		if len(public) > 0 {
+			problem["detail"] = strings.Join(public.Errors(), "; ")
+			errs := make([]interface{}, len(public))
+			for i, err := range public {
+				errs[i] = err.JSON()
+			}
+			problem["errors"] = errs
+		}
+		data, err := json.Marshal(problem)
+		if err != nil {
+			c.renderError(err)
+			return
+		}
+		c.writeBody(status, MIMEProblemJSON, data)
+	}
+}
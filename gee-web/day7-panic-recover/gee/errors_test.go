@@ -0,0 +1,77 @@
+package gee
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestContextError(t *testing.T) {
+	c := &Context{}
+	c.Error(errors.New("db down"))
+	c.Error(errors.New("bad id")).SetType(ErrorTypePublic).SetMeta(H{"field": "id"})
+	if got := c.Errors.ByType(ErrorTypePublic).Errors(); !reflect.DeepEqual(got, []string{"bad id"}) {
+		t.Fatalf("unexpected public errors %v", got)
+	}
+	if got := c.Errors.ByType(ErrorTypeAny).Errors(); len(got) != 2 {
+		t.Fatalf("unexpected errors %v", got)
+	}
+	if got := c.Errors.Last().JSON(); !reflect.DeepEqual(got, H{"message": "bad id", "field": "id"}) {
+		t.Fatalf("unexpected JSON %v", got)
+	}
+	if !errors.Is(c.Errors[0], c.Errors[0].Err) {
+		t.Fatal("Error should unwrap to the recorded error")
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	r := New()
+	r.Use(ErrorHandler())
+	r.GET("/private", func(c *Context) {
+		c.Error(errors.New("connection refused"))
+	})
+	r.GET("/public", func(c *Context) {
+		c.AbortWithError(http.StatusConflict, errors.New("name taken")).SetType(ErrorTypePublic).SetMeta(H{"name": "gee"})
+	}, func(c *Context) {
+		t.Error("AbortWithError should stop the chain")
+	})
+	r.GET("/written", func(c *Context) {
+		c.Error(errors.New("ignored"))
+		c.String(http.StatusOK, "ok")
+	})
+	r.NoRoute(func(c *Context) {
+		c.AbortWithError(http.StatusNotFound, errors.New("no such page: "+c.Path)).SetType(ErrorTypePublic)
+	})
+
+	tests := []struct {
+		path string
+		code int
+		body H
+	}{
+		{"/private", http.StatusInternalServerError, H{"type": "about:blank", "title": "Internal Server Error", "status": 500.0}},
+		{"/public", http.StatusConflict, H{"type": "about:blank", "title": "Conflict", "status": 409.0, "detail": "name taken",
+			"errors": []interface{}{map[string]interface{}{"message": "name taken", "name": "gee"}}}},
+		{"/missing", http.StatusNotFound, H{"type": "about:blank", "title": "Not Found", "status": 404.0, "detail": "no such page: /missing",
+			"errors": []interface{}{map[string]interface{}{"message": "no such page: /missing"}}}},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		var body H
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: invalid body %q", tt.path, w.Body.String())
+		}
+		if w.Code != tt.code || w.Header().Get("Content-Type") != MIMEProblemJSON || !reflect.DeepEqual(body, tt.body) {
+			t.Errorf("%s: got %d %q %v", tt.path, w.Code, w.Header().Get("Content-Type"), body)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/written", nil))
+	if w.Body.String() != "ok" {
+		t.Fatalf("ErrorHandler must not touch a written response, got %q", w.Body.String())
+	}
+}
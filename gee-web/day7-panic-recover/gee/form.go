@@ -0,0 +1,204 @@
+package gee
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+查询参数与表单：
+  - Query 系列方法读取 URL 中的查询参数，解析结果在本次请求内缓存
+  - PostForm 系列方法读取请求体中的表单（application/x-www-form-urlencoded 或 multipart/form-data），
+    与 c.Req.FormValue 一样也包括查询参数，同名时请求体中的值在前
+  - 数组形式为重复的键（ids=1&ids=2），映射形式为带方括号的键（user[name]=a&user[age]=1）
+multipart 表单按 Engine.MaxMultipartMemory 解析，超出部分由 net/http 写入临时文件
+*/
+
+func (c *Context) initQueryCache() {
+	if c.queryCache == nil {
+		c.queryCache = c.Req.URL.Query()
+	}
+}
+
+// Query 返回查询参数 key 的第一个值，不存在时返回空字符串
+func (c *Context) Query(key string) string {
+	value, _ := c.GetQuery(key)
+	return value
+}
+
+// DefaultQuery 返回查询参数 key 的第一个值，不存在时返回 defaultValue
+func (c *Context) DefaultQuery(key, defaultValue string) string {
+	if value, ok := c.GetQuery(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// GetQuery 返回查询参数 key 的第一个值，以及该参数是否存在（?key= 也算存在）
+func (c *Context) GetQuery(key string) (string, bool) {
+	if values, ok := c.GetQueryArray(key); ok {
+		return values[0], true
+	}
+	return "", false
+}
+
+// QueryArray 返回查询参数 key 的所有值
+func (c *Context) QueryArray(key string) []string {
+	values, _ := c.GetQueryArray(key)
+	return values
+}
+
+// GetQueryArray 返回查询参数 key 的所有值，以及该参数是否存在
+func (c *Context) GetQueryArray(key string) ([]string, bool) {
+	c.initQueryCache()
+	values, ok := c.queryCache[key]
+	return values, ok && len(values) > 0
+}
+
+// QueryMap 返回形如 key[k]=v 的查询参数组成的映射
+func (c *Context) QueryMap(key string) map[string]string {
+	m, _ := c.GetQueryMap(key)
+	return m
+}
+
+// GetQueryMap 返回形如 key[k]=v 的查询参数组成的映射，以及是否至少有一个这样的参数
+func (c *Context) GetQueryMap(key string) (map[string]string, bool) {
+	c.initQueryCache()
+	return bracketMap(c.queryCache, key)
+}
+
+// parseForm 解析请求体中的表单，请求不是 multipart 时按普通表单解析
+// 每个请求只解析一次，之后的调用直接返回第一次的结果
+func (c *Context) parseForm() error {
+	if c.formParsed {
+		return c.formErr
+	}
+	c.formParsed = true
+	if err := c.Req.ParseMultipartForm(c.engine.MaxMultipartMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		c.formErr = err
+	}
+	return c.formErr
+}
+
+// postForm 返回解析后的表单，解析失败时只在第一次解析时记录一个私有错误，并返回已解析的部分
+func (c *Context) postForm() url.Values {
+	parsed := c.formParsed
+	if err := c.parseForm(); err != nil && !parsed {
+		c.Error(err)
+	}
+	return c.Req.Form
+}
+
+// PostForm 返回表单字段 key 的第一个值，不存在时返回空字符串
+func (c *Context) PostForm(key string) string {
+	value, _ := c.GetPostForm(key)
+	return value
+}
+
+// DefaultPostForm 返回表单字段 key 的第一个值，不存在时返回 defaultValue
+func (c *Context) DefaultPostForm(key, defaultValue string) string {
+	if value, ok := c.GetPostForm(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// GetPostForm 返回表单字段 key 的第一个值，以及该字段是否存在
+func (c *Context) GetPostForm(key string) (string, bool) {
+	if values, ok := c.GetPostFormArray(key); ok {
+		return values[0], true
+	}
+	return "", false
+}
+
+// PostFormArray 返回表单字段 key 的所有值
+func (c *Context) PostFormArray(key string) []string {
+	values, _ := c.GetPostFormArray(key)
+	return values
+}
+
+// GetPostFormArray 返回表单字段 key 的所有值，以及该字段是否存在
+func (c *Context) GetPostFormArray(key string) ([]string, bool) {
+	values, ok := c.postForm()[key]
+	return values, ok && len(values) > 0
+}
+
+// PostFormMap 返回形如 key[k]=v 的表单字段组成的映射
+func (c *Context) PostFormMap(key string) map[string]string {
+	m, _ := c.GetPostFormMap(key)
+	return m
+}
+
+// GetPostFormMap 返回形如 key[k]=v 的表单字段组成的映射，以及是否至少有一个这样的字段
+func (c *Context) GetPostFormMap(key string) (map[string]string, bool) {
+	return bracketMap(c.postForm(), key)
+}
+
+// bracketMap 收集 values 中形如 key[k] 的键，同一个 k 有多个值时取第一个
+func bracketMap(values url.Values, key string) (map[string]string, bool) {
+	m := make(map[string]string)
+	prefix := key + "["
+	for k, v := range values {
+		if len(v) == 0 || !strings.HasPrefix(k, prefix) || !strings.HasSuffix(k, "]") {
+			continue
+		}
+		name := k[len(prefix) : len(k)-1]
+		if name == "" || strings.ContainsAny(name, "[]") {
+			continue
+		}
+		m[name] = v[0]
+	}
+	return m, len(m) > 0
+}
+
+// MultipartForm 解析并返回 multipart 表单，包括其中上传的文件
+func (c *Context) MultipartForm() (*multipart.Form, error) {
+	if err := c.Req.ParseMultipartForm(c.engine.MaxMultipartMemory); err != nil {
+		return nil, err
+	}
+	return c.Req.MultipartForm, nil
+}
+
+// FormFile 返回 multipart 表单中字段 name 的第一个文件
+func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	if c.Req.MultipartForm == nil {
+		if err := c.Req.ParseMultipartForm(c.engine.MaxMultipartMemory); err != nil {
+			return nil, err
+		}
+	}
+	f, fh, err := c.Req.FormFile(name)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	return fh, nil
+}
+
+// SaveUploadedFile 把上传的文件保存到 dst，dst 所在的目录不存在时会被创建
+// dst 由调用者决定，不要直接使用客户端提供的文件名（fh.Filename）拼接路径
+func (c *Context) SaveUploadedFile(fh *multipart.FileHeader, dst string) error {
+	src, err := fh.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
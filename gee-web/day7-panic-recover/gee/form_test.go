@@ -0,0 +1,156 @@
+package gee
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestQueryAccessors(t *testing.T) {
+	req := httptest.NewRequest("GET", "/?name=gee&empty=&ids=1&ids=2&user[name]=alice&user[age]=30&user[]=x&user[a][b]=y", nil)
+	c := &Context{Req: req}
+
+	if c.Query("name") != "gee" || c.Query("missing") != "" {
+		t.Error("Query returned unexpected values")
+	}
+	if c.DefaultQuery("missing", "def") != "def" || c.DefaultQuery("empty", "def") != "" {
+		t.Error("DefaultQuery should only fall back when the key is absent")
+	}
+	if v, ok := c.GetQuery("empty"); !ok || v != "" {
+		t.Errorf("GetQuery(empty) = %q, %v", v, ok)
+	}
+	if got := c.QueryArray("ids"); !reflect.DeepEqual(got, []string{"1", "2"}) {
+		t.Errorf("QueryArray = %v", got)
+	}
+	if _, ok := c.GetQueryArray("missing"); ok {
+		t.Error("GetQueryArray(missing) should report false")
+	}
+	if got := c.QueryMap("user"); !reflect.DeepEqual(got, map[string]string{"name": "alice", "age": "30"}) {
+		t.Errorf("QueryMap = %v", got)
+	}
+	if _, ok := c.GetQueryMap("ids"); ok {
+		t.Error("GetQueryMap(ids) should report false")
+	}
+}
+
+func TestPostFormAccessors(t *testing.T) {
+	r := New()
+	var got []interface{}
+	r.POST("/form", func(c *Context) {
+		got = []interface{}{
+			c.PostForm("name"),
+			c.DefaultPostForm("missing", "def"),
+			c.PostFormArray("tags"),
+			c.PostFormMap("meta"),
+		}
+		c.Status(http.StatusOK)
+	})
+
+	body := "name=gee&tags=a&tags=b&meta[lang]=go&meta[kind]=web"
+	req := httptest.NewRequest("POST", "/form?tags=c", strings.NewReader(body))
+	req.Header.Set("Content-Type", MIMEPOSTForm)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := []interface{}{
+		"gee",
+		"def",
+		[]string{"a", "b", "c"}, // 请求体中的值在查询参数之前
+		map[string]string{"lang": "go", "kind": "web"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestPostFormParseErrorRecordedOnce(t *testing.T) {
+	r := New()
+	var errs int
+	r.POST("/form", func(c *Context) {
+		for i := 0; i < 3; i++ {
+			c.PostForm("name")
+			c.GetPostFormMap("meta")
+		}
+		errs = len(c.Errors)
+		c.Status(http.StatusOK)
+	})
+	req := httptest.NewRequest("POST", "/form", strings.NewReader("not a multipart body"))
+	req.Header.Set("Content-Type", MIMEMultipartPOSTForm+"; boundary=x")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if errs != 1 {
+		t.Fatalf("parse error should be recorded once, got %d errors", errs)
+	}
+}
+
+func newMultipartRequest(t *testing.T, fields map[string]string, files map[string]string) *http.Request {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	for name, content := range files {
+		fw, err := mw.CreateFormFile(name, name+".txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(content))
+	}
+	mw.Close()
+	req := httptest.NewRequest("POST", "/upload", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestUpload(t *testing.T) {
+	dir := t.TempDir()
+	r := New()
+	r.MaxMultipartMemory = 8 // 超出部分写入临时文件，不影响读取
+	r.POST("/upload", func(c *Context) {
+		fh, err := c.FormFile("avatar")
+		if err != nil {
+			c.String(http.StatusBadRequest, "%v", err)
+			return
+		}
+		form, err := c.MultipartForm()
+		if err != nil {
+			c.String(http.StatusBadRequest, "%v", err)
+			return
+		}
+		dst := filepath.Join(dir, c.PostForm("user"), "avatar.txt")
+		if err := c.SaveUploadedFile(fh, dst); err != nil {
+			c.String(http.StatusInternalServerError, "%v", err)
+			return
+		}
+		c.String(http.StatusOK, "%s %d %d", fh.Filename, fh.Size, len(form.File["avatar"]))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newMultipartRequest(t, map[string]string{"user": "alice"}, map[string]string{"avatar": "hello, gee"}))
+	if w.Code != http.StatusOK || w.Body.String() != "avatar.txt 10 1" {
+		t.Fatalf("got %d %q", w.Code, w.Body.String())
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "alice", "avatar.txt"))
+	if err != nil || string(data) != "hello, gee" {
+		t.Errorf("saved file = %q, %v", data, err)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newMultipartRequest(t, map[string]string{"user": "bob"}, nil))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), http.ErrMissingFile.Error()) {
+		t.Errorf("missing file: got %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/upload", strings.NewReader("user=carol"))
+	req.Header.Set("Content-Type", MIMEPOSTForm)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("non-multipart request: got %d %q", w.Code, w.Body.String())
+	}
+}
@@ -5,7 +5,8 @@ import (
     "net/http"
     "strings"
     "html/template"
-    "path"
+    "os"
+    "sync"
 )
 
 type HandlerFunc func(*Context)
@@ -33,8 +34,27 @@ type (
         router *router  //实际的路由器，用于处理和匹配路由请求
         groups []*RouterGroup //store all groups
 
-        htmlTemplates *template.Template
+        html htmlRenderer //HTML 模板集
         funcMap template.FuncMap
+        secureJSONPrefix string //SecureJSON 在 JSON 数组前添加的前缀
+
+        //解析 multipart 表单时最多保存在内存中的字节数，超出部分写入临时文件，默认 32 MB
+        MaxMultipartMemory int64
+
+        pool sync.Pool //复用 Context 对象，避免每个请求都分配
+
+        namedRoutes map[string]string //路由名 -> 路由模式，用于 URL 反向生成
+
+        srv *http.Server //Run 系列方法使用的 http.Server，第一次调用 Server() 时创建
+        srvOnce sync.Once
+
+        //未匹配到路由（404）与请求方法不允许（405）时的处理函数，
+        //以及在其前面加上全局中间件后得到的完整处理链
+        noRoute []HandlerFunc
+        noMethod []HandlerFunc
+        allNoRoute []HandlerFunc
+        allNoMethod []HandlerFunc
+        allOptions []HandlerFunc
     }
 )
 
@@ -42,14 +62,43 @@ type (
 func New() *Engine {
     //创建一个新的 Engine 实例，并通过 newRouter() 函数创建一个新的路由器实例，
     //并将其赋值给 router 字段
-    engine := &Engine{router: newRouter()}
+    engine := &Engine{router: newRouter(), namedRoutes: make(map[string]string)}
     //双向连接
     engine.RouterGroup = &RouterGroup{engine: engine}  //根路由组，默认为空
     //将根路由组加入groups
     engine.groups = []*RouterGroup{engine.RouterGroup}
+    engine.pool.New = func() interface{} {
+        return &Context{engine: engine, Params: make(Params, 0, engine.router.maxParams)}
+    }
+    engine.secureJSONPrefix = defaultSecureJSONPrefix
+    engine.MaxMultipartMemory = defaultMultipartMemory
+    engine.noRoute = []HandlerFunc{serveNotFound}
+    engine.noMethod = []HandlerFunc{serveMethodNotAllowed}
+    engine.rebuildFallbackHandlers()
     return engine
 }
 
+//NoRoute 设置未匹配到路由时的处理函数，默认返回 404
+//处理链中只包含全局（Engine 上注册的）中间件，不包含任何分组的中间件
+func (engine *Engine) NoRoute(handlers ...HandlerFunc) {
+    engine.noRoute = handlers
+    engine.rebuildFallbackHandlers()
+}
+
+//NoMethod 设置路径存在但请求方法不匹配时的处理函数，默认返回 405
+//执行前 Allow 头部已经设置好
+func (engine *Engine) NoMethod(handlers ...HandlerFunc) {
+    engine.noMethod = handlers
+    engine.rebuildFallbackHandlers()
+}
+
+//全局中间件变化或者 NoRoute/NoMethod 被修改后，重新计算 404/405/OPTIONS 的处理链
+func (engine *Engine) rebuildFallbackHandlers() {
+    engine.allNoRoute = engine.combineHandlers(engine.noRoute)
+    engine.allNoMethod = engine.combineHandlers(engine.noMethod)
+    engine.allOptions = engine.combineHandlers([]HandlerFunc{serveOptions})
+}
+
 
 
 
@@ -87,63 +136,102 @@ func (group *RouterGroup) Group(prefix string) *RouterGroup {
 //这样实现，我们既可以像原来一样添加路由，也可以通过分组添加路由
 
 // Use is defined to add middleware to the group
+//处理链在注册路由时确定，因此中间件需要在注册路由之前添加
 func (group *RouterGroup) Use(middlewares ...HandlerFunc) {
     group.middlewares = append(group.middlewares, middlewares...)
+    if group == group.engine.RouterGroup {
+        group.engine.rebuildFallbackHandlers()
+    }
+}
+
+//返回该分组的完整处理链：从根分组到当前分组依次的中间件，再加上 handlers
+//只沿着 parent 查找，因此 /v1 的中间件不会作用于 /v10 下的路由
+func (group *RouterGroup) combineHandlers(handlers []HandlerFunc) []HandlerFunc {
+    groups := make([]*RouterGroup, 0)
+    for g := group; g != nil; g = g.parent {
+        groups = append(groups, g)
+    }
+    size := len(handlers)
+    for _, g := range groups {
+        size += len(g.middlewares)
+    }
+    chain := make([]HandlerFunc, 0, size)
+    for i := len(groups) - 1; i >= 0; i-- {
+        chain = append(chain, groups[i].middlewares...)
+    }
+    return append(chain, handlers...)
 }
 
 
-//comp为传入的路由组件
-func (group *RouterGroup) addRoute(method string, comp string, handler HandlerFunc) {
+//comp为传入的路由组件，handlers 的最后一个是路由的处理函数，前面的是该路由独有的中间件
+func (group *RouterGroup) addRoute(method string, comp string, handlers []HandlerFunc) *Route {
+    if len(handlers) == 0 {
+        panic("gee: there must be at least one handler for route " + method + " " + group.prefix + comp)
+    }
     pattern := group.prefix + comp
     log.Printf("Route %4s - %s", method, pattern)
-    group.engine.router.addRoute(method, pattern, handler)
+    group.engine.router.addRoute(method, pattern, group.combineHandlers(handlers))
+    return &Route{engine: group.engine, Method: method, Pattern: pattern}
 }
 
 // GET defines the method to add GET request
-func (group *RouterGroup) GET(pattern string, handler HandlerFunc) {
-	group.addRoute("GET", pattern, handler)
+func (group *RouterGroup) GET(pattern string, handlers ...HandlerFunc) *Route {
+	return group.addRoute("GET", pattern, handlers)
 }
 
 
-func (group *RouterGroup) POST(pattern string, handler HandlerFunc) {
-	group.addRoute("POST", pattern, handler)
+func (group *RouterGroup) POST(pattern string, handlers ...HandlerFunc) *Route {
+	return group.addRoute("POST", pattern, handlers)
 }
 
+func (group *RouterGroup) PUT(pattern string, handlers ...HandlerFunc) *Route {
+	return group.addRoute("PUT", pattern, handlers)
+}
 
+func (group *RouterGroup) DELETE(pattern string, handlers ...HandlerFunc) *Route {
+	return group.addRoute("DELETE", pattern, handlers)
+}
 
+func (group *RouterGroup) PATCH(pattern string, handlers ...HandlerFunc) *Route {
+	return group.addRoute("PATCH", pattern, handlers)
+}
 
-/*
-通过调用 createStaticHandler 方法可以创建一个处理静态文件的路由处理器，并将其注册到路由器中，以便在收到对应路径的请求时提供静态文件的访问。
-*/
+//未注册 HEAD 时，HEAD 请求会自动回退到同一路径的 GET 处理函数
+func (group *RouterGroup) HEAD(pattern string, handlers ...HandlerFunc) *Route {
+	return group.addRoute("HEAD", pattern, handlers)
+}
+
+//未注册 OPTIONS 时，OPTIONS 请求会自动返回 204 和 Allow 头部
+func (group *RouterGroup) OPTIONS(pattern string, handlers ...HandlerFunc) *Route {
+	return group.addRoute("OPTIONS", pattern, handlers)
+}
 
-//创建静态文件处理器
-//relativePath 服务器中存放静态资源的相对路径
-func (group *RouterGroup) createStaticHandler(relativePath string, fs http.FileSystem) HandlerFunc {
-    //得到静态文件的绝对路径
-    absolutePath := path.Join(group.prefix, relativePath)
-    //创建一个文件服务器 fileServer，它会剥离请求路径中的 absolutePath 部分，以便在文件系统中查找和提供静态文件
-    fileServer := http.StripPrefix(absolutePath, http.FileServer(fs))
-    return func(c *Context) {
-        file := c.Param("filepath")
-        // Check if file exists and/or if we have permission to access it
-        if _, err := fs.Open(file); err != nil {
-            c.Status(http.StatusNotFound)
-            return
+//Any 使用同一个处理函数注册所有的标准请求方法，返回的 Route 的 Method 为 GET
+func (group *RouterGroup) Any(pattern string, handlers ...HandlerFunc) *Route {
+    var route *Route
+    for _, method := range anyMethods {
+        if r := group.addRoute(method, pattern, handlers); route == nil {
+            route = r
         }
+    }
+    return route
+}
 
-        //将请求交给文件服务器处理，将文件的内容作为响应返回给客户端
-        fileServer.ServeHTTP(c.Writer, c.Req)
+//Handle 用于注册任意请求方法的路由，例如 WebDAV 的 PROPFIND
+func (group *RouterGroup) Handle(method string, pattern string, handlers ...HandlerFunc) *Route {
+    if method == "" || strings.ToUpper(method) != method {
+        panic("gee: http method " + method + " is not valid")
     }
+    return group.addRoute(method, pattern, handlers)
 }
 
-//用于在路由组中注册静态文件服务的方法
-func (group *RouterGroup) Static(relativePath string, root string) {
 
-	handler := group.createStaticHandler(relativePath, http.Dir(root))
-    //构建了一个完整的 URL 模式，表示匹配 relativePath 前缀下的所有路径，并将通配符部分作为参数 "filepath" 提供给处理函数
-	urlPattern := path.Join(relativePath, "/*filepath")
-	// 将 URL 模式和处理函数注册为 GET 请求的处理器，即当匹配到该 URL 模式的 GET 请求时，将会执行对应的处理函数来提供静态文件服务
-	group.GET(urlPattern, handler)
+
+
+//用于在路由组中注册静态文件服务的方法，root 为服务器上存放静态资源的目录
+//等价于 StaticFS(relativePath, os.DirFS(root))
+func (group *RouterGroup) Static(relativePath string, root string) {
+    group.StaticFS(relativePath, os.DirFS(root))
 }
 
 // 设置模板引擎的自定义函数映射
@@ -151,30 +239,26 @@ func (engine *Engine) SetFuncMap(funcMap template.FuncMap) {
 	engine.funcMap = funcMap
 }
 
-//用于加载指定模式下的HTML模板文件
-func (engine *Engine) LoadHTMLGlob(pattern string) {
-	engine.htmlTemplates = template.Must(template.New("").Funcs(engine.funcMap).ParseGlob(pattern))
+//设置 SecureJSON 使用的前缀，默认为 while(1);
+func (engine *Engine) SecureJSONPrefix(prefix string) {
+	engine.secureJSONPrefix = prefix
 }
 
-
-func (engine *Engine) Run(addr string) (err error) {
-	return http.ListenAndServe(addr, engine)
+//用于加载指定模式下的HTML模板文件
+//模板中默认可以使用 url 函数生成命名路由的 URL，例如 {{url "user.show" .ID}}
+func (engine *Engine) LoadHTMLGlob(pattern string) {
+	engine.LoadHTMLFS(osFS{}, pattern)
 }
 
 //engine结构体定义了serveHTTP方法，因此实现了http.Handler接口
 
-//ServeHTTP 函数也有变化，当我们接收到一个具体请求时，要判断该请求适用于哪些中间件，在这里我们简单通过 URL 的前缀来判断。得到中间件列表后，赋值给 c.handlers
+//ServeHTTP 从对象池中取出一个 Context，交给路由处理，处理完成后放回对象池
+//每条路由的处理链在注册时已经计算好，这里不再需要遍历分组拼接中间件
 func (engine *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-    var middlewares []HandlerFunc //用于存储匹配到的中间件函数
-    for _, group := range engine.groups {  //通过遍历路由组来查找
-        if strings.HasPrefix(req.URL.Path, group.prefix) {
-            middlewares = append(middlewares, group.middlewares...)
-        }
-    }
-
-    c := newContext(w, req)  //创建上下文处理请求
-    //将处理函数从路由组中添加到上下文中
-    c.handlers = middlewares
-    c.engine = engine
+    c := engine.pool.Get().(*Context)
+    c.reset(w, req)
     engine.router.handle(c)
+    //处理函数只设置了状态码而没有写入响应体时（如 c.Status(204)），在这里写出响应头
+    c.writermem.WriteHeaderNow()
+    engine.pool.Put(c)
 }
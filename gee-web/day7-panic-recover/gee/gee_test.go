@@ -1,6 +1,11 @@
 package gee
 
-import "testing"
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
 
 func TestNestedGroup(t *testing.T) {
 	r := New()
@@ -13,4 +18,146 @@ func TestNestedGroup(t *testing.T) {
 	if v3.prefix != "/v1/v2/v3" {
 		t.Fatal("v2 prefix should be /v1/v2")
 	}
-}
\ No newline at end of file
+}
+
+func newMethodTestEngine() *Engine {
+	r := New()
+	r.GET("/users/:id", func(c *Context) {
+		c.String(http.StatusOK, "get %s", c.Param("id"))
+	})
+	r.PUT("/users/:id", func(c *Context) {
+		c.String(http.StatusOK, "put %s", c.Param("id"))
+	})
+	r.DELETE("/users/:id", func(c *Context) {
+		c.Status(http.StatusNoContent)
+	})
+	r.Any("/any", func(c *Context) {
+		c.String(http.StatusOK, c.Method)
+	})
+	r.Handle("PROPFIND", "/dav", func(c *Context) {
+		c.String(http.StatusOK, "dav")
+	})
+	return r
+}
+
+func TestHTTPMethods(t *testing.T) {
+	r := newMethodTestEngine()
+	tests := []struct {
+		method, path string
+		code         int
+		body, allow  string
+	}{
+		{"GET", "/users/1", http.StatusOK, "get 1", ""},
+		{"PUT", "/users/1", http.StatusOK, "put 1", ""},
+		{"DELETE", "/users/1", http.StatusNoContent, "", ""},
+		{"PATCH", "/any", http.StatusOK, "PATCH", ""},
+		{"PROPFIND", "/dav", http.StatusOK, "dav", ""},
+		{"POST", "/users/1", http.StatusMethodNotAllowed, "405 METHOD NOT ALLOWED: POST /users/1\n", "DELETE, GET, HEAD, OPTIONS, PUT"},
+		{"OPTIONS", "/users/1", http.StatusNoContent, "", "DELETE, GET, HEAD, OPTIONS, PUT"},
+		{"HEAD", "/users/1", http.StatusOK, "", ""},
+		{"POST", "/nothing", http.StatusNotFound, "404 NOT FOUND: /nothing\n", ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.code || w.Body.String() != tt.body || w.Header().Get("Allow") != tt.allow {
+			t.Errorf("%s %s: got %d %q Allow=%q, want %d %q Allow=%q",
+				tt.method, tt.path, w.Code, w.Body.String(), w.Header().Get("Allow"), tt.code, tt.body, tt.allow)
+		}
+	}
+}
+
+func TestMiddlewareChains(t *testing.T) {
+	var trace []string
+	mark := func(name string) HandlerFunc {
+		return func(c *Context) {
+			trace = append(trace, name)
+			c.Next()
+		}
+	}
+	r := New()
+	r.Use(mark("global"))
+	v1 := r.Group("/v1")
+	v1.Use(mark("v1"))
+	v10 := r.Group("/v10")
+	v10.Use(mark("v10"))
+	admin := v1.Group("/admin")
+	admin.Use(mark("admin"))
+	v1.GET("/hello", mark("route"), func(c *Context) { trace = append(trace, "hello") })
+	v10.GET("/hello", func(c *Context) { trace = append(trace, "hello10") })
+	admin.GET("/", func(c *Context) { trace = append(trace, "admin") })
+	r.NoRoute(mark("noroute"), func(c *Context) { c.String(http.StatusNotFound, "custom 404") })
+
+	tests := []struct {
+		path  string
+		trace []string
+	}{
+		{"/v1/hello", []string{"global", "v1", "route", "hello"}},
+		// /v1 的中间件不能作用于 /v10
+		{"/v10/hello", []string{"global", "v10", "hello10"}},
+		{"/v1/admin", []string{"global", "v1", "admin", "admin"}},
+		// 404 只执行全局中间件，不执行 /v1 的中间件
+		{"/v1/missing", []string{"global", "noroute"}},
+	}
+	for _, tt := range tests {
+		trace = nil
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if !reflect.DeepEqual(trace, tt.trace) {
+			t.Errorf("%s: trace %v, want %v", tt.path, trace, tt.trace)
+		}
+	}
+}
+
+func TestContextReuse(t *testing.T) {
+	r := New()
+	var got []string
+	r.GET("/users/:id/books/:book", func(c *Context) {
+		got = append(got, c.Param("id")+","+c.Param("book"))
+	})
+	r.GET("/users/:id", func(c *Context) {
+		got = append(got, c.Param("id")+","+c.Param("book"))
+	})
+	for _, path := range []string{"/users/1/books/2", "/users/3", "/users/4/books/5"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	// 复用的 Context 不能带有上一个请求的参数
+	want := []string{"1,2", "3,", "4,5"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+// discardWriter 是一个不做任何事情的 http.ResponseWriter，避免基准测试统计到记录响应的开销
+type discardWriter struct{ header http.Header }
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(int)             {}
+
+// benchmarkServeHTTP 测量一次完整请求的开销（两层中间件加处理函数）
+// 引入 Context 池与 Params 之前的基线（把本函数复制到 8c0e192^ 上运行，-count=10 取中位数）：
+//
+//	BenchmarkServeHTTPStatic   639 ns/op   280 B/op   6 allocs/op
+//	BenchmarkServeHTTPParam   1035 ns/op   568 B/op   7 allocs/op
+//
+// 之后两者都是 0 B/op、0 allocs/op
+func benchmarkServeHTTP(b *testing.B, path string) {
+	r := New()
+	r.Use(func(c *Context) { c.Next() })
+	v1 := r.Group("/v1")
+	v1.Use(func(c *Context) { c.Next() })
+	v1.GET("/users/new", func(c *Context) {})
+	v1.GET("/users/:id/books/:book", func(c *Context) { _ = c.Param("book") })
+	w := &discardWriter{header: make(http.Header)}
+	req := httptest.NewRequest("GET", path, nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.ServeHTTP(w, req)
+	}
+}
+
+func BenchmarkServeHTTPStatic(b *testing.B) { benchmarkServeHTTP(b, "/v1/users/new") }
+
+func BenchmarkServeHTTPParam(b *testing.B) { benchmarkServeHTTP(b, "/v1/users/42/books/7") }
@@ -1,3 +1,8 @@
 module gee
 
-go 1.20
+go 1.21
+
+require (
+	google.golang.org/protobuf v1.34.2
+	gopkg.in/yaml.v3 v3.0.1
+)
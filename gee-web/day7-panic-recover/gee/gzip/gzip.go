@@ -0,0 +1,256 @@
+// Package gzip 提供按 Accept-Encoding 压缩响应的中间件，支持 gzip 与 deflate
+//
+//	r := gee.New()
+//	r.Use(gzip.Gzip(gzip.DefaultCompression, gzip.WithExcludedPaths("/metrics")))
+//
+// 是否压缩在第一次写入响应体时决定：响应已经设置了 Content-Encoding、状态码为 206，
+// 或 Content-Type 是图片、视频、压缩包等已压缩的格式时原样输出。
+package gzip
+
+import (
+	"compress/flate"
+	stdgzip "compress/gzip"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gee"
+)
+
+// 压缩级别，与 compress/gzip 相同
+const (
+	BestCompression    = stdgzip.BestCompression
+	BestSpeed          = stdgzip.BestSpeed
+	DefaultCompression = stdgzip.DefaultCompression
+	NoCompression      = stdgzip.NoCompression
+	HuffmanOnly        = stdgzip.HuffmanOnly
+)
+
+// 默认不压缩的请求扩展名，这些文件本身已经是压缩格式
+var defaultExcludedExtensions = []string{
+	".png", ".gif", ".jpeg", ".jpg", ".webp", ".ico", ".mp3", ".mp4", ".webm", ".woff", ".woff2",
+	".zip", ".gz", ".tgz", ".br", ".bz2", ".xz", ".7z", ".rar",
+}
+
+// 已压缩的 Content-Type 前缀
+var compressedContentTypes = []string{
+	"image/", "video/", "audio/", "font/woff",
+	"application/zip", "application/gzip", "application/x-gzip", "application/x-brotli",
+	"application/x-7z-compressed", "application/x-rar-compressed", "application/x-protobuf",
+}
+
+type options struct {
+	excludedExtensions map[string]struct{}
+	excludedPaths      []string
+	excludedRegexps    []*regexp.Regexp
+}
+
+// Option 是 Gzip 的可选配置
+type Option func(*options)
+
+// WithExcludedExtensions 设置不压缩的请求路径扩展名，替换默认的列表
+func WithExcludedExtensions(exts ...string) Option {
+	return func(o *options) {
+		o.excludedExtensions = make(map[string]struct{}, len(exts))
+		for _, ext := range exts {
+			o.excludedExtensions[strings.ToLower(ext)] = struct{}{}
+		}
+	}
+}
+
+// WithExcludedPaths 设置不压缩的请求路径前缀
+func WithExcludedPaths(prefixes ...string) Option {
+	return func(o *options) {
+		o.excludedPaths = append(o.excludedPaths, prefixes...)
+	}
+}
+
+// WithExcludedPathsRegexps 设置不压缩的请求路径正则表达式，表达式不合法时 panic
+func WithExcludedPathsRegexps(exprs ...string) Option {
+	return func(o *options) {
+		for _, expr := range exprs {
+			o.excludedRegexps = append(o.excludedRegexps, regexp.MustCompile(expr))
+		}
+	}
+}
+
+func (o *options) excluded(req *http.Request) bool {
+	p := req.URL.Path
+	if _, ok := o.excludedExtensions[strings.ToLower(path.Ext(p))]; ok {
+		return true
+	}
+	for _, prefix := range o.excludedPaths {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	for _, re := range o.excludedRegexps {
+		if re.MatchString(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// compressor 是 gzip.Writer 与 flate.Writer 的共同方法
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// Gzip 返回压缩响应的中间件，level 为压缩级别，不合法时 panic
+func Gzip(level int, opts ...Option) gee.HandlerFunc {
+	o := &options{}
+	WithExcludedExtensions(defaultExcludedExtensions...)(o)
+	for _, opt := range opts {
+		opt(o)
+	}
+	if _, err := stdgzip.NewWriterLevel(io.Discard, level); err != nil {
+		panic(err)
+	}
+	pools := map[string]*sync.Pool{
+		"gzip": {New: func() interface{} {
+			w, _ := stdgzip.NewWriterLevel(io.Discard, level)
+			return w
+		}},
+		"deflate": {New: func() interface{} {
+			w, _ := flate.NewWriter(io.Discard, level)
+			return w
+		}},
+	}
+
+	return func(c *gee.Context) {
+		if c.Req.Header.Get("Upgrade") != "" || o.excluded(c.Req) {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiate(c.Req.Header.Get("Accept-Encoding"))
+		if encoding == "" || c.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		w := &writer{ResponseWriter: c.Writer, encoding: encoding, pool: pools[encoding]}
+		c.Writer = w
+		defer func() {
+			w.close()
+			c.Writer = w.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// negotiate 返回 Accept-Encoding 中权重最高的 gzip 或 deflate，权重相同时优先 gzip，都不接受时返回空字符串
+func negotiate(accept string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(accept, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "deflate" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > bestQ || q == bestQ && coding == "gzip" && q > 0 {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+// writer 包装 gee.ResponseWriter，在第一次写入时决定是否压缩
+type writer struct {
+	gee.ResponseWriter
+	encoding string
+	pool     *sync.Pool
+	decided  bool
+	cw       compressor // 不为 nil 说明正在压缩
+}
+
+// decide 根据已设置的头部与状态码判断是否压缩，必须在写出响应头之前调用
+func (w *writer) decide(data []byte) {
+	w.decided = true
+	header := w.Header()
+	status := w.Status()
+	// 1xx、204、304 没有响应体，206 的内容范围针对未压缩的数据
+	if header.Get("Content-Encoding") != "" || status < http.StatusOK || status == http.StatusPartialContent ||
+		status == http.StatusNoContent || status == http.StatusNotModified {
+		return
+	}
+	ctype := header.Get("Content-Type")
+	if ctype == "" {
+		// 从 Flush 或 WriteHeaderNow 调用时还没有数据，无法推断类型，不压缩，Content-Type 留给 net/http 处理
+		if len(data) == 0 {
+			return
+		}
+		// 压缩后 net/http 无法再根据内容推断类型，这里提前推断
+		ctype = http.DetectContentType(data)
+		header.Set("Content-Type", ctype)
+	}
+	for _, prefix := range compressedContentTypes {
+		if strings.HasPrefix(ctype, prefix) && !strings.HasPrefix(ctype, "image/svg") {
+			return
+		}
+	}
+	header.Set("Content-Encoding", w.encoding)
+	// 压缩后的长度未知，使用分块传输
+	header.Del("Content-Length")
+	w.cw = w.pool.Get().(compressor)
+	w.cw.Reset(w.ResponseWriter)
+}
+
+func (w *writer) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.decide(data)
+	}
+	if w.cw == nil {
+		return w.ResponseWriter.Write(data)
+	}
+	w.WriteHeaderNow()
+	return w.cw.Write(data)
+}
+
+func (w *writer) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeaderNow 写出响应头，之后不能再改变是否压缩，因此先做决定
+func (w *writer) WriteHeaderNow() {
+	if !w.decided {
+		w.decide(nil)
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Flush 把已压缩的数据发送给客户端，用于流式响应
+// 在第一次写入之前 Flush 会写出响应头，因此同样需要先决定是否压缩
+func (w *writer) Flush() {
+	if !w.decided {
+		w.decide(nil)
+	}
+	if w.cw != nil {
+		w.cw.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// close 写出压缩数据的结尾，并把压缩器放回对象池
+func (w *writer) close() {
+	if w.cw == nil {
+		return
+	}
+	w.cw.Close()
+	w.cw.Reset(io.Discard)
+	w.pool.Put(w.cw)
+	w.cw = nil
+}
@@ -0,0 +1,155 @@
+package gzip
+
+import (
+	"bytes"
+	"compress/flate"
+	stdgzip "compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"gee"
+	"gee/internal/geetest"
+)
+
+var largeBody = strings.Repeat(`{"name":"geektutu","lang":"go"}`, 100)
+
+func newTestEngine(opts ...Option) *gee.Engine {
+	r := gee.New()
+	r.Use(Gzip(DefaultCompression, opts...))
+	jsonHandler := func(c *gee.Context) {
+		c.SetHeader("Content-Length", "1")
+		c.Data(http.StatusOK, []byte(largeBody))
+	}
+	r.GET("/json", jsonHandler)
+	r.HEAD("/json", jsonHandler)
+	r.GET("/image.png", func(c *gee.Context) {
+		c.Data(http.StatusOK, []byte("png"))
+	})
+	r.GET("/photo", func(c *gee.Context) {
+		c.SetHeader("Content-Type", "image/jpeg")
+		c.Data(http.StatusOK, []byte("jpeg"))
+	})
+	r.GET("/metrics", func(c *gee.Context) {
+		c.String(http.StatusOK, largeBody)
+	})
+	r.GET("/stream", func(c *gee.Context) {
+		c.SetHeader("Content-Type", "text/event-stream")
+		for i := 0; i < 3; i++ {
+			io.WriteString(c.Writer, "data: tick\n\n")
+			c.Writer.Flush()
+		}
+	})
+	r.GET("/flush-first", func(c *gee.Context) {
+		c.SetHeader("Content-Type", "text/event-stream")
+		c.Writer.Flush()
+		io.WriteString(c.Writer, "data: hello\n\n")
+	})
+	r.GET("/empty", func(c *gee.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	r.GET("/not-modified", func(c *gee.Context) {
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+	})
+	r.GET("/flush-untyped", func(c *gee.Context) {
+		c.Writer.Flush()
+		io.WriteString(c.Writer, largeBody)
+	})
+	return r
+}
+
+func decode(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var rd io.Reader
+	switch encoding {
+	case "gzip":
+		zr, err := stdgzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rd = zr
+	case "deflate":
+		rd = flate.NewReader(bytes.NewReader(body))
+	default:
+		return string(body)
+	}
+	data, err := io.ReadAll(rd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestGzip(t *testing.T) {
+	r := newTestEngine(WithExcludedPaths("/metrics"))
+	tests := []struct {
+		path, accept, encoding string
+	}{
+		{"/json", "gzip, deflate, br", "gzip"},
+		{"/json", "deflate", "deflate"},
+		{"/json", "gzip;q=0.5, deflate", "deflate"},
+		{"/json", "gzip;q=0", ""},
+		{"/json", "", ""},
+		{"/image.png", "gzip", ""},
+		{"/photo", "gzip", ""},
+		{"/metrics", "gzip", ""},
+	}
+	for _, tt := range tests {
+		w := geetest.PerformRequest(r, "GET", tt.path, "Accept-Encoding", tt.accept)
+		if got := w.Header().Get("Content-Encoding"); got != tt.encoding {
+			t.Errorf("%s (%s): Content-Encoding = %q, want %q", tt.path, tt.accept, got, tt.encoding)
+			continue
+		}
+		if tt.encoding != "" && (w.Header().Get("Content-Length") != "" || w.Body.Len() >= len(largeBody)) {
+			t.Errorf("%s (%s): expected compressed body without Content-Length", tt.path, tt.accept)
+		}
+		if tt.path == "/json" && decode(t, tt.encoding, w.Body.Bytes()) != largeBody {
+			t.Errorf("%s (%s): body does not round-trip", tt.path, tt.accept)
+		}
+	}
+
+	if w := geetest.PerformRequest(r, "GET", "/json", "Accept-Encoding", "gzip"); w.Header().Get("Vary") != "Accept-Encoding" || w.Header().Get("Content-Type") == "" {
+		t.Errorf("unexpected headers %v", w.Header())
+	}
+	if w := geetest.PerformRequest(r, "GET", "/empty", "Accept-Encoding", "gzip"); w.Code != http.StatusNoContent || w.Body.Len() != 0 || w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("empty responses must not be compressed, got %d %v", w.Code, w.Header())
+	}
+	if w := geetest.PerformRequest(r, "GET", "/not-modified", "Accept-Encoding", "gzip"); w.Code != http.StatusNotModified || w.Body.Len() != 0 || w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("304 responses must not be compressed, got %d %v", w.Code, w.Header())
+	}
+	if w := geetest.PerformRequest(r, http.MethodHead, "/json", "Accept-Encoding", "gzip"); w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("HEAD responses must not be compressed, got %v", w.Header())
+	}
+}
+
+func TestGzipFlush(t *testing.T) {
+	w := geetest.PerformRequest(newTestEngine(), "GET", "/stream", "Accept-Encoding", "gzip")
+	if !w.Flushed || w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected flushed event stream, got %v", w.Header())
+	}
+	if body := decode(t, "gzip", w.Body.Bytes()); body != strings.Repeat("data: tick\n\n", 3) {
+		t.Fatalf("unexpected body %q", body)
+	}
+}
+
+func TestGzipFlushUntyped(t *testing.T) {
+	// Flush 时还没有数据也没有 Content-Type，无法判断类型，因此不压缩
+	w := geetest.PerformRequest(newTestEngine(), "GET", "/flush-untyped", "Accept-Encoding", "gzip")
+	if w.Result().Header.Get("Content-Encoding") != "" || w.Body.String() != largeBody {
+		t.Fatalf("untyped response flushed before writing should not be compressed, got %v", w.Result().Header)
+	}
+}
+
+func TestGzipFlushBeforeWrite(t *testing.T) {
+	w := geetest.PerformRequest(newTestEngine(), "GET", "/flush-first", "Accept-Encoding", "gzip")
+	// 使用 Result 取得写出响应头时的快照，而不是之后仍可修改的头部
+	header := w.Result().Header
+	if header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("headers sent without Content-Encoding: %v", header)
+	}
+	if body := decode(t, "gzip", w.Body.Bytes()); body != "data: hello\n\n" {
+		t.Fatalf("unexpected body %q", body)
+	}
+}
@@ -0,0 +1,209 @@
+package gee
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+/*
+HTML 模板：
+  - 全局模板集（LoadHTMLGlob / LoadHTMLFS）：所有模板解析到同一个 template.Template 中，按模板名渲染
+  - 命名模板集（AddHTMLSet / LoadHTMLLayout）：每个页面单独解析为一个模板集，从布局文件开始渲染，
+    页面通过 {{define "content"}} 覆盖布局中的 {{block "content" .}}，不同页面可以定义同名的 block
+渲染时先查找命名模板集，找不到时在全局模板集中查找。
+调试模式下每次渲染前检查模板文件是否有变化（增加、删除或修改时间改变），有变化时重新解析，修改模板无需重启
+*/
+
+// htmlSource 是一个模板集及其来源，调试模式下据此重新解析
+type htmlSource struct {
+	fsys     fs.FS
+	patterns []string
+	isSet    bool   // 是否为命名模板集
+	entry    string // 命名模板集从该模板（第一个文件）开始渲染；全局模板集为空，按模板名渲染
+	tmpl     *template.Template
+	stamps   map[string]time.Time // 解析时各模板文件的修改时间
+}
+
+type htmlRenderer struct {
+	mu     sync.RWMutex // protect following
+	debug  bool
+	global *htmlSource
+	sets   map[string]*htmlSource
+}
+
+// osFS 把操作系统的文件系统包装为 fs.FS，允许绝对路径与 ".."，供 LoadHTMLGlob 使用
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error)                 { return os.Open(name) }
+func (osFS) ReadFile(name string) ([]byte, error)              { return os.ReadFile(name) }
+func (osFS) Stat(name string) (fs.FileInfo, error)             { return os.Stat(name) }
+func (osFS) Glob(pattern string) (matches []string, err error) { return filepath.Glob(pattern) }
+
+var htmlBufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// SetHTMLDebug 开启或关闭模板的调试模式，开启后修改模板文件会在下一次渲染时生效
+// 每次渲染都需要检查文件，只应在开发时开启
+func (engine *Engine) SetHTMLDebug(debug bool) {
+	engine.html.mu.Lock()
+	engine.html.debug = debug
+	engine.html.mu.Unlock()
+}
+
+// LoadHTMLFS 从 fsys（如 embed.FS）中加载与 patterns 匹配的模板作为全局模板集，模板名为文件名
+func (engine *Engine) LoadHTMLFS(fsys fs.FS, patterns ...string) {
+	src := &htmlSource{fsys: fsys, patterns: patterns}
+	engine.mustParse(src)
+	engine.html.mu.Lock()
+	engine.html.global = src
+	engine.html.mu.Unlock()
+}
+
+// AddHTMLSet 添加一个名为 name 的模板集，由 fsys 中与 patterns 依次匹配的文件组成，
+// 渲染时从第一个文件（通常是布局）开始执行，之后的文件用于填充布局中的 block：
+//
+//	r.AddHTMLSet("user", templates, "layouts/base.tmpl", "partials/*.tmpl", "pages/user.tmpl")
+//	c.HTML(http.StatusOK, "user", data)
+func (engine *Engine) AddHTMLSet(name string, fsys fs.FS, patterns ...string) {
+	src := &htmlSource{fsys: fsys, patterns: patterns, isSet: true}
+	engine.mustParse(src)
+	engine.html.mu.Lock()
+	if engine.html.sets == nil {
+		engine.html.sets = make(map[string]*htmlSource)
+	}
+	engine.html.sets[name] = src
+	engine.html.mu.Unlock()
+}
+
+// LoadHTMLLayout 为 fsys 中与 pages 匹配的每个页面添加一个模板集，模板集名为页面的路径，
+// 每个模板集由 layouts 匹配的文件（第一个为入口布局）加上该页面组成：
+//
+//	r.LoadHTMLLayout(templates, "pages/*.tmpl", "layouts/base.tmpl", "partials/*.tmpl")
+//	c.HTML(http.StatusOK, "pages/user.tmpl", data)
+func (engine *Engine) LoadHTMLLayout(fsys fs.FS, pages string, layouts ...string) {
+	files, err := fs.Glob(fsys, pages)
+	if err != nil {
+		panic(err)
+	}
+	if len(files) == 0 {
+		panic(fmt.Sprintf("gee: pattern %q matches no pages", pages))
+	}
+	for _, page := range files {
+		engine.AddHTMLSet(page, fsys, append(append([]string(nil), layouts...), page)...)
+	}
+}
+
+// renderHTML 渲染名为 name 的模板集或全局模板集中名为 name 的模板
+func (engine *Engine) renderHTML(w io.Writer, name string, data interface{}) error {
+	h := &engine.html
+	h.mu.RLock()
+	src, ok := h.sets[name]
+	if !ok {
+		src = h.global
+	}
+	debug := h.debug
+	h.mu.RUnlock()
+	if src == nil {
+		return errors.New("gee: no HTML templates loaded")
+	}
+
+	if debug {
+		h.mu.Lock()
+		if src.changed() {
+			if err := engine.parse(src); err != nil {
+				h.mu.Unlock()
+				return err
+			}
+		}
+		h.mu.Unlock()
+	}
+	h.mu.RLock()
+	tmpl, entry := src.tmpl, src.entry
+	h.mu.RUnlock()
+	if entry == "" {
+		entry = name
+	}
+	return tmpl.ExecuteTemplate(w, entry, data)
+}
+
+func (engine *Engine) mustParse(src *htmlSource) {
+	if err := engine.parse(src); err != nil {
+		panic(err)
+	}
+}
+
+// parse 解析 src 中的模板文件，并记录各文件的修改时间
+func (engine *Engine) parse(src *htmlSource) error {
+	files, stamps, err := src.files()
+	if err != nil {
+		return err
+	}
+	tmpl := template.New("").Funcs(engine.templateFuncs())
+	for _, file := range files {
+		data, err := fs.ReadFile(src.fsys, file)
+		if err != nil {
+			return err
+		}
+		// 与 template.ParseFS 一样，以文件名作为模板名
+		if _, err := tmpl.New(path.Base(filepath.ToSlash(file))).Parse(string(data)); err != nil {
+			return err
+		}
+	}
+	src.tmpl = tmpl
+	src.stamps = stamps
+	if src.isSet {
+		src.entry = path.Base(filepath.ToSlash(files[0]))
+	}
+	return nil
+}
+
+// files 按 patterns 的顺序返回匹配的文件（去重）及其修改时间
+func (src *htmlSource) files() ([]string, map[string]time.Time, error) {
+	var files []string
+	stamps := make(map[string]time.Time)
+	for _, pattern := range src.patterns {
+		matches, err := fs.Glob(src.fsys, pattern)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(matches) == 0 {
+			return nil, nil, fmt.Errorf("gee: template pattern %q matches no files", pattern)
+		}
+		for _, file := range matches {
+			if _, ok := stamps[file]; ok {
+				continue
+			}
+			info, err := fs.Stat(src.fsys, file)
+			if err != nil {
+				return nil, nil, err
+			}
+			files = append(files, file)
+			stamps[file] = info.ModTime()
+		}
+	}
+	return files, stamps, nil
+}
+
+// changed 判断模板文件自上次解析后是否有变化
+func (src *htmlSource) changed() bool {
+	_, stamps, err := src.files()
+	if err != nil || len(stamps) != len(src.stamps) {
+		return true
+	}
+	for file, modTime := range stamps {
+		if old, ok := src.stamps[file]; !ok || !old.Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
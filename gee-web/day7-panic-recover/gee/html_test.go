@@ -0,0 +1,97 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"gee/internal/geetest"
+)
+
+var testTemplates = fstest.MapFS{
+	"layouts/base.tmpl":  {Data: []byte(`<title>{{block "title" .}}gee{{end}}</title>{{template "nav" .}}{{block "content" .}}{{end}}`)},
+	"partials/nav.tmpl":  {Data: []byte(`{{define "nav"}}<nav>{{url "user" 1}}</nav>{{end}}`)},
+	"pages/user.tmpl":    {Data: []byte(`{{define "title"}}user {{.}}{{end}}{{define "content"}}<p>{{.}}</p>{{end}}`)},
+	"pages/about.tmpl":   {Data: []byte(`{{define "content"}}<p>about</p>{{end}}`)},
+	"pages/broken.tmpl":  {Data: []byte(`{{define "content"}}<p>partial</p>{{.Missing.Field}}{{end}}`)},
+	"global/hello.tmpl":  {Data: []byte(`hello {{.}}`)},
+	"global/secret.tmpl": {Data: []byte(`secret`)},
+}
+
+func renderHTMLPage(r *Engine, name string, data interface{}) *httptest.ResponseRecorder {
+	r.GET("/render", func(c *Context) { c.HTML(http.StatusOK, name, data) })
+	return geetest.PerformRequest(r, "GET", "/render")
+}
+
+func TestHTMLLayout(t *testing.T) {
+	newEngine := func() *Engine {
+		r := New()
+		r.GET("/users/:id", func(c *Context) {}).Name("user")
+		r.LoadHTMLFS(testTemplates, "global/*.tmpl")
+		r.LoadHTMLLayout(testTemplates, "pages/*.tmpl", "layouts/base.tmpl", "partials/*.tmpl")
+		return r
+	}
+	tests := []struct {
+		name string
+		data interface{}
+		code int
+		body string
+	}{
+		{"pages/user.tmpl", "geektutu", http.StatusOK, "<title>user geektutu</title><nav>/users/1</nav><p>geektutu</p>"},
+		{"pages/about.tmpl", nil, http.StatusOK, "<title>gee</title><nav>/users/1</nav><p>about</p>"},
+		{"hello.tmpl", "gee", http.StatusOK, "hello gee"},
+	}
+	for _, tt := range tests {
+		w := renderHTMLPage(newEngine(), tt.name, tt.data)
+		if w.Code != tt.code || w.Body.String() != tt.body || w.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+			t.Errorf("%s: got %d %q", tt.name, w.Code, w.Body.String())
+		}
+	}
+
+	w := renderHTMLPage(newEngine(), "pages/broken.tmpl", "x")
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "partial") {
+		t.Fatalf("template errors should produce a clean 500, got %d %q", w.Code, w.Body.String())
+	}
+	if w := renderHTMLPage(New(), "hello.tmpl", nil); w.Code != http.StatusInternalServerError {
+		t.Fatalf("rendering without templates should fail, got %d", w.Code)
+	}
+}
+
+func TestHTMLDebugReload(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "index.tmpl")
+	write := func(content string, modTime time.Time) {
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(file, modTime, modTime)
+	}
+	now := time.Now()
+	write("v1", now.Add(-time.Minute))
+
+	r := New()
+	r.LoadHTMLGlob(filepath.Join(dir, "*.tmpl"))
+	r.GET("/", func(c *Context) { c.HTML(http.StatusOK, "index.tmpl", nil) })
+	get := func() string {
+		return geetest.PerformRequest(r, "GET", "/").Body.String()
+	}
+
+	write("v2", now)
+	if body := get(); body != "v1" {
+		t.Fatalf("templates must not reload outside debug mode, got %q", body)
+	}
+	r.SetHTMLDebug(true)
+	if body := get(); body != "v2" {
+		t.Fatalf("expected reloaded template, got %q", body)
+	}
+	os.WriteFile(filepath.Join(dir, "extra.tmpl"), []byte("{{define \"x\"}}{{end}}"), 0644)
+	write("v3 {{template \"x\"}}", now.Add(time.Minute))
+	if body := get(); body != "v3 " {
+		t.Fatalf("expected new files to be picked up, got %q", body)
+	}
+}
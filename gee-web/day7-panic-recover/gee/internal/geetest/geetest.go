@@ -0,0 +1,24 @@
+// Package geetest 提供 gee 及其中间件的测试共用的辅助函数
+package geetest
+
+import (
+	"net/http"
+	"net/http/httptest"
+)
+
+// PerformRequest 向 h 发送一个没有请求体的请求并返回记录下的响应
+// header 为交替出现的头部名与值，例如 "Accept", "application/json"
+func PerformRequest(h http.Handler, method, target string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	return Serve(h, req)
+}
+
+// Serve 把 req 交给 h 处理并返回记录下的响应，用于需要请求体或自定义 RemoteAddr 等字段的请求
+func Serve(h http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
@@ -0,0 +1,290 @@
+// Package jwt 提供基于 HS256 签名的 JSON Web Token 认证中间件，只依赖标准库。
+//
+//	config := jwt.Config{Secret: []byte(os.Getenv("JWT_SECRET")), Issuer: "gee"}
+//	r.POST("/login", jwt.LoginHandler(config, time.Hour, authenticate))
+//	api := r.Group("/api")
+//	api.Use(jwt.New(config))
+//	api.GET("/me", func(c *gee.Context) {
+//		user, _ := c.Get(gee.AuthUserKey)
+//		c.JSON(http.StatusOK, gee.H{"user": user})
+//	})
+package jwt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gee"
+)
+
+// ClaimsKey 是中间件在 Context 中保存已验证的 Claims 的键
+const ClaimsKey = "jwt.claims"
+
+var (
+	ErrTokenMissing     = errors.New("jwt: token missing")
+	ErrTokenMalformed   = errors.New("jwt: token malformed")
+	ErrSignatureInvalid = errors.New("jwt: signature invalid")
+	ErrTokenExpired     = errors.New("jwt: token expired")
+	ErrTokenNotValidYet = errors.New("jwt: token not valid yet")
+	ErrInvalidIssuer    = errors.New("jwt: invalid issuer")
+	ErrInvalidAudience  = errors.New("jwt: invalid audience")
+	ErrEmptySecret      = errors.New("jwt: empty secret")
+)
+
+// Claims 是 token 的载荷，数字按 JSON 解码为 float64
+type Claims map[string]interface{}
+
+// Subject 返回 sub 声明，通常为用户名或用户 ID
+func (c Claims) Subject() string {
+	sub, _ := c["sub"].(string)
+	return sub
+}
+
+// time 返回时间类声明（exp、nbf、iat），不存在时 ok 为 false
+func (c Claims) time(name string) (t time.Time, ok bool, err error) {
+	v, exists := c[name]
+	if !exists {
+		return time.Time{}, false, nil
+	}
+	switch n := v.(type) {
+	case float64:
+		return time.Unix(int64(n), 0), true, nil
+	case json.Number:
+		i, err := n.Int64()
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("%w: %s is not a number", ErrTokenMalformed, name)
+		}
+		return time.Unix(i, 0), true, nil
+	case int64:
+		return time.Unix(n, 0), true, nil
+	case int:
+		return time.Unix(int64(n), 0), true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("%w: %s is not a number", ErrTokenMalformed, name)
+}
+
+// hasAudience 判断 aud 声明（字符串或字符串数组）是否包含 audience
+func (c Claims) hasAudience(audience string) bool {
+	switch aud := c["aud"].(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, a := range aud {
+			if s, ok := a.(string); ok && s == audience {
+				return true
+			}
+		}
+	case []string:
+		for _, a := range aud {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Config 是中间件与 LoginHandler 的配置
+type Config struct {
+	// Secret 是 HMAC-SHA256 的密钥，不能为空
+	Secret []byte
+	// Issuer 不为空时，签发的 token 带有该 iss，验证时要求 iss 与之相同
+	Issuer string
+	// Audience 不为空时，签发的 token 带有该 aud，验证时要求 aud 包含它
+	Audience string
+	// Leeway 是检查 exp、nbf、iat 时允许的时钟偏差
+	Leeway time.Duration
+	// Validate 在签名与标准声明都通过后调用，用于检查自定义声明，返回错误时拒绝请求
+	Validate func(Claims) error
+	// TimeFunc 返回当前时间，默认为 time.Now，主要用于测试
+	TimeFunc func() time.Time
+}
+
+func (config *Config) now() time.Time {
+	if config.TimeFunc != nil {
+		return config.TimeFunc()
+	}
+	return time.Now()
+}
+
+// header 固定为 HS256，只生成一次
+var encodedHeader = encode([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func signature(secret []byte, signingInput string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
+
+// Sign 使用 HS256 对 claims 签名，返回紧凑格式的 token
+func Sign(secret []byte, claims Claims) (string, error) {
+	if len(secret) == 0 {
+		return "", ErrEmptySecret
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := encodedHeader + "." + encode(payload)
+	return signingInput + "." + encode(signature(secret, signingInput)), nil
+}
+
+// Parse 验证 token 的签名与声明，返回其中的 Claims
+//   - config.Secret 为空时返回 ErrEmptySecret，空密钥签名的 token 任何人都能伪造
+//   - header 的 alg 必须为 HS256，拒绝 "none" 等其他算法
+//   - exp、nbf、iat 存在时按 config.Leeway 检查
+//   - config.Issuer、config.Audience 不为空时检查 iss、aud
+//   - 最后调用 config.Validate
+func Parse(token string, config Config) (Claims, error) {
+	if len(config.Secret) == 0 {
+		return nil, ErrEmptySecret
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrTokenMalformed
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, ErrTokenMalformed
+	}
+	if header.Alg != "HS256" {
+		return nil, fmt.Errorf("%w: unexpected algorithm %q", ErrSignatureInvalid, header.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	// 先验证签名再解析载荷，未经验证的内容不参与任何判断
+	if !hmac.Equal(sig, signature(config.Secret, parts[0]+"."+parts[1])) {
+		return nil, ErrSignatureInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims == nil {
+		return nil, ErrTokenMalformed
+	}
+	if err := claims.verify(config); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// verify 检查标准声明与自定义声明
+func (c Claims) verify(config Config) error {
+	now := config.now()
+	if exp, ok, err := c.time("exp"); err != nil {
+		return err
+	} else if ok && !now.Before(exp.Add(config.Leeway)) {
+		return ErrTokenExpired
+	}
+	if nbf, ok, err := c.time("nbf"); err != nil {
+		return err
+	} else if ok && now.Add(config.Leeway).Before(nbf) {
+		return ErrTokenNotValidYet
+	}
+	if iat, ok, err := c.time("iat"); err != nil {
+		return err
+	} else if ok && now.Add(config.Leeway).Before(iat) {
+		return ErrTokenNotValidYet
+	}
+	if config.Issuer != "" {
+		if iss, _ := c["iss"].(string); iss != config.Issuer {
+			return ErrInvalidIssuer
+		}
+	}
+	if config.Audience != "" && !c.hasAudience(config.Audience) {
+		return ErrInvalidAudience
+	}
+	if config.Validate != nil {
+		return config.Validate(c)
+	}
+	return nil
+}
+
+// New 返回验证 Authorization: Bearer <token> 的中间件
+// 验证通过时 Claims 保存在 c.Get(ClaimsKey) 中，sub 保存在 c.Get(gee.AuthUserKey) 中；
+// 失败时返回 401 和 WWW-Authenticate 头部，错误通过 c.Error 记录
+func New(config Config) gee.HandlerFunc {
+	if len(config.Secret) == 0 {
+		panic("jwt: Config.Secret can not be empty")
+	}
+	return func(c *gee.Context) {
+		claims, err := Parse(bearerToken(c.Req), config)
+		if err != nil {
+			c.SetHeader("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.Error(err).SetType(gee.ErrorTypePublic)
+			c.Fail(http.StatusUnauthorized, err.Error())
+			return
+		}
+		c.Set(ClaimsKey, claims)
+		c.Set(gee.AuthUserKey, claims.Subject())
+		c.Next()
+	}
+}
+
+// bearerToken 返回 Authorization 头部中的 Bearer token，方案名不区分大小写
+func bearerToken(req *http.Request) string {
+	scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// LoginHandler 返回签发 token 的处理函数：authenticate 校验请求中的凭据并返回要写入 token 的声明
+// （至少应包含 sub），返回错误时响应 401；成功时补充 iat、exp 以及配置的 iss、aud，响应
+//
+//	{"token": "...", "expires_at": "2006-01-02T15:04:05Z"}
+func LoginHandler(config Config, ttl time.Duration, authenticate func(*gee.Context) (Claims, error)) gee.HandlerFunc {
+	if len(config.Secret) == 0 {
+		panic("jwt: Config.Secret can not be empty")
+	}
+	return func(c *gee.Context) {
+		claims, err := authenticate(c)
+		if err != nil {
+			c.Error(err).SetType(gee.ErrorTypePublic)
+			c.Fail(http.StatusUnauthorized, err.Error())
+			return
+		}
+		if claims == nil {
+			claims = Claims{}
+		}
+		now := config.now()
+		expiresAt := now.Add(ttl)
+		claims["iat"] = now.Unix()
+		claims["exp"] = expiresAt.Unix()
+		if config.Issuer != "" {
+			claims["iss"] = config.Issuer
+		}
+		if config.Audience != "" {
+			claims["aud"] = config.Audience
+		}
+		token, err := Sign(config.Secret, claims)
+		if err != nil {
+			c.Error(err)
+			c.Fail(http.StatusInternalServerError, "Internal Server Error")
+			return
+		}
+		c.JSON(http.StatusOK, gee.H{"token": token, "expires_at": expiresAt.UTC().Format(time.RFC3339)})
+	}
+}
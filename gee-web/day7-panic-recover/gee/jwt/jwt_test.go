@@ -0,0 +1,197 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gee"
+	"gee/internal/geetest"
+)
+
+var (
+	secret = []byte("test-secret")
+	now    = time.Unix(1700000000, 0)
+)
+
+func testConfig() Config {
+	return Config{
+		Secret:   secret,
+		Issuer:   "gee",
+		Leeway:   30 * time.Second,
+		TimeFunc: func() time.Time { return now },
+	}
+}
+
+func mustSign(t *testing.T, claims Claims) string {
+	t.Helper()
+	token, err := Sign(secret, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestParse(t *testing.T) {
+	config := testConfig()
+	config.Audience = "api"
+	config.Validate = func(c Claims) error {
+		if c["role"] == "banned" {
+			return errors.New("user banned")
+		}
+		return nil
+	}
+	valid := func(extra Claims) Claims {
+		c := Claims{"sub": "alice", "iss": "gee", "aud": "api", "exp": now.Add(time.Minute).Unix()}
+		for k, v := range extra {
+			c[k] = v
+		}
+		return c
+	}
+
+	tests := []struct {
+		name   string
+		claims Claims
+		err    error
+	}{
+		{"valid", valid(nil), nil},
+		{"audience array", valid(Claims{"aud": []string{"web", "api"}}), nil},
+		{"expired within leeway", valid(Claims{"exp": now.Add(-10 * time.Second).Unix()}), nil},
+		{"expired", valid(Claims{"exp": now.Add(-time.Minute).Unix()}), ErrTokenExpired},
+		{"not before within leeway", valid(Claims{"nbf": now.Add(10 * time.Second).Unix()}), nil},
+		{"not valid yet", valid(Claims{"nbf": now.Add(time.Minute).Unix()}), ErrTokenNotValidYet},
+		{"issued in future", valid(Claims{"iat": now.Add(time.Hour).Unix()}), ErrTokenNotValidYet},
+		{"wrong issuer", valid(Claims{"iss": "other"}), ErrInvalidIssuer},
+		{"wrong audience", valid(Claims{"aud": []string{"web"}}), ErrInvalidAudience},
+		{"bad exp type", valid(Claims{"exp": "tomorrow"}), ErrTokenMalformed},
+	}
+	for _, tt := range tests {
+		claims, err := Parse(mustSign(t, tt.claims), config)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if err == nil && claims.Subject() != "alice" {
+			t.Errorf("%s: sub = %q", tt.name, claims.Subject())
+		}
+	}
+
+	if _, err := Parse(mustSign(t, valid(Claims{"role": "banned"})), config); err == nil || err.Error() != "user banned" {
+		t.Errorf("custom validation: err = %v", err)
+	}
+}
+
+func TestParseRejectsTampering(t *testing.T) {
+	config := testConfig()
+	token := mustSign(t, Claims{"sub": "alice", "iss": "gee"})
+	parts := strings.Split(token, ".")
+
+	forged, _ := json.Marshal(Claims{"sub": "admin", "iss": "gee"})
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+	tests := []struct {
+		name, token string
+		err         error
+	}{
+		{"empty", "", ErrTokenMalformed},
+		{"two parts", parts[0] + "." + parts[1], ErrTokenMalformed},
+		{"forged payload", parts[0] + "." + base64.RawURLEncoding.EncodeToString(forged) + "." + parts[2], ErrSignatureInvalid},
+		{"alg none", none + "." + parts[1] + ".", ErrSignatureInvalid},
+		{"bad signature encoding", parts[0] + "." + parts[1] + ".!!!", ErrTokenMalformed},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(tt.token, config); !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	config.Secret = []byte("other-secret")
+	if _, err := Parse(token, config); !errors.Is(err, ErrSignatureInvalid) {
+		t.Errorf("wrong secret: err = %v", err)
+	}
+
+	// 用空密钥签名的 token 不能通过空 Secret 的验证
+	signingInput := parts[0] + "." + base64.RawURLEncoding.EncodeToString(forged)
+	emptyKeyToken := signingInput + "." + base64.RawURLEncoding.EncodeToString(signature(nil, signingInput))
+	if claims, err := Parse(emptyKeyToken, Config{}); !errors.Is(err, ErrEmptySecret) {
+		t.Errorf("empty secret: claims = %v, err = %v", claims, err)
+	}
+}
+
+func newTestEngine(config Config) *gee.Engine {
+	r := gee.New()
+	r.POST("/login", LoginHandler(config, time.Hour, func(c *gee.Context) (Claims, error) {
+		if c.PostForm("password") != "pass" {
+			return nil, errors.New("invalid credentials")
+		}
+		return Claims{"sub": c.PostForm("user")}, nil
+	}))
+	api := r.Group("/api")
+	api.Use(New(config))
+	api.GET("/me", func(c *gee.Context) {
+		user, _ := c.Get(gee.AuthUserKey)
+		claims, _ := c.Get(ClaimsKey)
+		c.JSON(http.StatusOK, gee.H{"user": user, "iss": claims.(Claims)["iss"]})
+	})
+	return r
+}
+
+func TestLoginAndMiddleware(t *testing.T) {
+	r := newTestEngine(testConfig())
+
+	login := func(password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/login", strings.NewReader("user=alice&password="+password))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return geetest.Serve(r, req)
+	}
+
+	if w := login("wrong"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("login with wrong password: got %d", w.Code)
+	}
+	w := login("pass")
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: got %d %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Token     string `json:"token"`
+		ExpiresAt string `json:"expires_at"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if want := now.Add(time.Hour).UTC().Format(time.RFC3339); resp.ExpiresAt != want {
+		t.Errorf("expires_at = %q, want %q", resp.ExpiresAt, want)
+	}
+
+	w = geetest.PerformRequest(r, "GET", "/api/me", "Authorization", "bearer "+resp.Token)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"user":"alice"`) || !strings.Contains(w.Body.String(), `"iss":"gee"`) {
+		t.Errorf("authorized request: got %d %s", w.Code, w.Body.String())
+	}
+	for _, auth := range []string{"", "Basic " + resp.Token, "Bearer " + resp.Token + "x"} {
+		w := geetest.PerformRequest(r, "GET", "/api/me", "Authorization", auth)
+		if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") != `Bearer error="invalid_token"` {
+			t.Errorf("Authorization %q: got %d %q", auth, w.Code, w.Header().Get("WWW-Authenticate"))
+		}
+	}
+
+	// token 过期后被拒绝
+	expired := testConfig()
+	expired.TimeFunc = func() time.Time { return now.Add(2 * time.Hour) }
+	w = geetest.PerformRequest(newTestEngine(expired), "GET", "/api/me", "Authorization", "Bearer "+resp.Token)
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "expired") {
+		t.Errorf("expired token: got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestNewRequiresSecret(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for empty secret")
+		}
+	}()
+	New(Config{})
+}
@@ -0,0 +1,167 @@
+package gee
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+/*
+请求范围内的键值存储：中间件通过 c.Set 保存数据（如当前用户、请求 ID），之后的处理函数通过 c.Get 等方法读取。
+处理函数可能把 Context 交给其他 goroutine 使用，因此读写都由读写锁保护。
+
+Context 同时实现了 context.Context，截止时间与取消信号来自 c.Req.Context()，
+可以直接传给数据库、RPC 等接受 context.Context 的调用：
+
+	rows, err := db.QueryContext(c, "SELECT ...")
+
+Context 由 Engine 复用，处理函数返回后不能再使用它，需要在后台继续使用时应传递 c.Req.Context()。
+*/
+
+// Set 保存一个键值对，只在本次请求内有效
+func (c *Context) Set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Keys == nil {
+		c.Keys = make(map[string]interface{})
+	}
+	c.Keys[key] = value
+}
+
+// Get 读取通过 Set 保存的值，以及该键是否存在
+func (c *Context) Get(key string) (value interface{}, exists bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	value, exists = c.Keys[key]
+	return
+}
+
+// MustGet 读取通过 Set 保存的值，键不存在时 panic
+func (c *Context) MustGet(key string) interface{} {
+	if value, exists := c.Get(key); exists {
+		return value
+	}
+	panic(fmt.Sprintf("gee: key %q does not exist", key))
+}
+
+// 以下方法读取指定类型的值，键不存在或类型不符时返回零值
+
+func (c *Context) GetString(key string) (s string) {
+	if val, ok := c.Get(key); ok && val != nil {
+		s, _ = val.(string)
+	}
+	return
+}
+
+func (c *Context) GetBool(key string) (b bool) {
+	if val, ok := c.Get(key); ok && val != nil {
+		b, _ = val.(bool)
+	}
+	return
+}
+
+func (c *Context) GetInt(key string) (i int) {
+	if val, ok := c.Get(key); ok && val != nil {
+		i, _ = val.(int)
+	}
+	return
+}
+
+func (c *Context) GetInt64(key string) (i int64) {
+	if val, ok := c.Get(key); ok && val != nil {
+		i, _ = val.(int64)
+	}
+	return
+}
+
+func (c *Context) GetUint(key string) (u uint) {
+	if val, ok := c.Get(key); ok && val != nil {
+		u, _ = val.(uint)
+	}
+	return
+}
+
+func (c *Context) GetUint64(key string) (u uint64) {
+	if val, ok := c.Get(key); ok && val != nil {
+		u, _ = val.(uint64)
+	}
+	return
+}
+
+func (c *Context) GetFloat64(key string) (f float64) {
+	if val, ok := c.Get(key); ok && val != nil {
+		f, _ = val.(float64)
+	}
+	return
+}
+
+func (c *Context) GetTime(key string) (t time.Time) {
+	if val, ok := c.Get(key); ok && val != nil {
+		t, _ = val.(time.Time)
+	}
+	return
+}
+
+func (c *Context) GetDuration(key string) (d time.Duration) {
+	if val, ok := c.Get(key); ok && val != nil {
+		d, _ = val.(time.Duration)
+	}
+	return
+}
+
+func (c *Context) GetStringSlice(key string) (ss []string) {
+	if val, ok := c.Get(key); ok && val != nil {
+		ss, _ = val.([]string)
+	}
+	return
+}
+
+func (c *Context) GetStringMap(key string) (sm map[string]interface{}) {
+	if val, ok := c.Get(key); ok && val != nil {
+		sm, _ = val.(map[string]interface{})
+	}
+	return
+}
+
+func (c *Context) GetStringMapString(key string) (sms map[string]string) {
+	if val, ok := c.Get(key); ok && val != nil {
+		sms, _ = val.(map[string]string)
+	}
+	return
+}
+
+// 确保 *Context 实现了 context.Context
+var _ context.Context = (*Context)(nil)
+
+// requestContext 返回请求的 context，没有请求时返回 context.Background()
+func (c *Context) requestContext() context.Context {
+	if c.Req == nil {
+		return context.Background()
+	}
+	return c.Req.Context()
+}
+
+// Deadline 返回请求的截止时间
+func (c *Context) Deadline() (deadline time.Time, ok bool) {
+	return c.requestContext().Deadline()
+}
+
+// Done 在请求被取消（如客户端断开连接、服务器关闭）或超过截止时间时关闭
+func (c *Context) Done() <-chan struct{} {
+	return c.requestContext().Done()
+}
+
+// Err 在 Done 关闭后返回取消的原因
+func (c *Context) Err() error {
+	return c.requestContext().Err()
+}
+
+// Value 先在通过 Set 保存的键值中查找字符串类型的 key，找不到时再查找请求的 context
+func (c *Context) Value(key interface{}) interface{} {
+	if k, ok := key.(string); ok {
+		if val, exists := c.Get(k); exists {
+			return val
+		}
+	}
+	return c.requestContext().Value(key)
+}
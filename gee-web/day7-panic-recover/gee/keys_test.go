@@ -0,0 +1,126 @@
+package gee
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestContextKeys(t *testing.T) {
+	c := &Context{}
+	if _, ok := c.Get("missing"); ok {
+		t.Fatal("Get on empty store should report missing")
+	}
+	now := time.Now()
+	c.Set("string", "value")
+	c.Set("bool", true)
+	c.Set("int", 42)
+	c.Set("int64", int64(-7))
+	c.Set("uint", uint(7))
+	c.Set("float64", 1.5)
+	c.Set("time", now)
+	c.Set("duration", time.Second)
+	c.Set("slice", []string{"a", "b"})
+	c.Set("map", map[string]interface{}{"k": 1})
+	c.Set("mapString", map[string]string{"k": "v"})
+
+	if c.GetString("string") != "value" || !c.GetBool("bool") || c.GetInt("int") != 42 ||
+		c.GetInt64("int64") != -7 || c.GetUint("uint") != 7 || c.GetFloat64("float64") != 1.5 ||
+		!c.GetTime("time").Equal(now) || c.GetDuration("duration") != time.Second ||
+		len(c.GetStringSlice("slice")) != 2 || c.GetStringMap("map")["k"] != 1 ||
+		c.GetStringMapString("mapString")["k"] != "v" {
+		t.Error("typed getters returned unexpected values")
+	}
+	// 类型不符或不存在时返回零值
+	if c.GetString("int") != "" || c.GetInt("string") != 0 || c.GetInt64("int") != 0 || c.GetBool("missing") {
+		t.Error("mismatched getters should return zero values")
+	}
+	if c.MustGet("int") != 42 {
+		t.Error("MustGet returned unexpected value")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("MustGet on a missing key should panic")
+			}
+		}()
+		c.MustGet("missing")
+	}()
+}
+
+func TestContextKeysConcurrent(t *testing.T) {
+	c := &Context{}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c.Set("n", i)
+			c.GetInt("n")
+		}(i)
+	}
+	wg.Wait()
+	if _, ok := c.Get("n"); !ok {
+		t.Error("expected key to be set")
+	}
+}
+
+func TestContextKeysResetBetweenRequests(t *testing.T) {
+	r := New()
+	r.GET("/", func(c *Context) {
+		if _, ok := c.Get("user"); ok {
+			c.String(http.StatusOK, "leaked")
+			return
+		}
+		c.Set("user", "alice")
+		c.String(http.StatusOK, "ok")
+	})
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		if w.Body.String() != "ok" {
+			t.Fatalf("request %d: got %q", i, w.Body.String())
+		}
+	}
+}
+
+type ctxKey struct{}
+
+func TestContextAsContextContext(t *testing.T) {
+	deadline := time.Now().Add(time.Hour)
+	reqCtx, cancel := context.WithDeadline(context.WithValue(context.Background(), ctxKey{}, "from request"), deadline)
+	req := httptest.NewRequest("GET", "/", nil).WithContext(reqCtx)
+
+	c := &Context{Req: req}
+	c.Set("user", "alice")
+
+	var ctx context.Context = c
+	if d, ok := ctx.Deadline(); !ok || !d.Equal(deadline) {
+		t.Errorf("Deadline = %v, %v", d, ok)
+	}
+	if ctx.Value("user") != "alice" || ctx.Value(ctxKey{}) != "from request" || ctx.Value("missing") != nil {
+		t.Error("Value did not consult the key store and request context")
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("Err before cancel = %v", ctx.Err())
+	}
+	cancel()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Done not closed after cancel")
+	}
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Errorf("Err after cancel = %v", ctx.Err())
+	}
+
+	// 没有请求时表现为 context.Background()
+	empty := &Context{}
+	if empty.Done() != nil || empty.Err() != nil || empty.Value("x") != nil {
+		t.Error("Context without request should behave like context.Background()")
+	}
+}
@@ -1,8 +1,17 @@
 package gee
 
 import (
-    "log"
-    "time"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 /*
@@ -10,14 +19,223 @@ import (
 它可以用作一个全局的日志中间件，用于记录每个请求的处理情况，方便调试、性能监控和故障排查。
 */
 
-//用于记录请求日志的中间件函数
+// 用于记录请求日志的中间件函数，使用默认配置：文本格式，输出到标准错误
 func Logger() HandlerFunc {
-    return func(c *Context) {
-        //Start time
-        t := time.Now()
-        //调用下一个中间件或请求处理函数
-        c.Next()
-        //计算并记录请求的处理时间和其他相关信息
-        log.Printf("[%d] %s in %v", c.StatusCode, c.Req.RequestURI, time.Since(t))
-    }
-}
\ No newline at end of file
+	return LoggerWithConfig(LoggerConfig{})
+}
+
+// LogParams 是一条请求日志包含的字段，传给 LogFormatter 生成日志
+type LogParams struct {
+	TimeStamp    time.Time     // 请求处理完成的时间
+	StatusCode   int           // 响应状态码
+	Latency      time.Duration // 处理耗时
+	ClientIP     string
+	Method       string
+	Path         string
+	RawQuery     string
+	Proto        string
+	BodySize     int // 响应体的字节数
+	UserAgent    string
+	Referer      string
+	RequestID    string
+	ErrorMessage string // 通过 c.Error 记录的错误
+}
+
+// LogFormatter 把一条请求日志格式化为一行文本（包含结尾的换行符）
+type LogFormatter func(params LogParams) string
+
+// LoggerConfig 是 LoggerWithConfig 的配置
+type LoggerConfig struct {
+	// Formatter 默认为 TextLogFormatter，可选 JSONLogFormatter、ApacheLogFormatter 或自定义
+	Formatter LogFormatter
+	// Output 默认为 os.Stderr，写入时按 Output 加锁（共用同一个 Output 的 Logger 共用一把锁），Output 本身不需要是并发安全的
+	Output io.Writer
+	// SkipPaths 中的路径不记录日志，例如健康检查接口
+	SkipPaths []string
+	// RequestIDHeader 是读取请求 ID 的头部，默认为 X-Request-ID；请求中没有时读取响应头部中的同名字段
+	RequestIDHeader string
+	// Slog 不为 nil 时，日志以结构化的属性写入 slog，忽略 Formatter 与 Output
+	// 5xx 记为 Error 级别，4xx 记为 Warn 级别，其余为 Info 级别
+	Slog *slog.Logger
+}
+
+// LoggerWithConfig 返回按 config 记录请求日志的中间件
+func LoggerWithConfig(config LoggerConfig) HandlerFunc {
+	formatter := config.Formatter
+	if formatter == nil {
+		formatter = TextLogFormatter
+	}
+	out := config.Output
+	if out == nil {
+		out = os.Stderr
+	}
+	requestIDHeader := config.RequestIDHeader
+	if requestIDHeader == "" {
+		requestIDHeader = "X-Request-ID"
+	}
+	// 请求在不同的 goroutine 中处理，多个 Logger 也可能共用同一个 Output，写入时按 Output 互斥
+	mu := outputLock(out)
+	skip := make(map[string]struct{}, len(config.SkipPaths))
+	for _, p := range config.SkipPaths {
+		skip[p] = struct{}{}
+	}
+
+	return func(c *Context) {
+		start := time.Now()
+		// 处理函数可能修改 c.Path，提前保存
+		path, rawQuery := c.Path, c.Req.URL.RawQuery
+		c.Next()
+		if _, ok := skip[path]; ok {
+			return
+		}
+
+		params := LogParams{
+			TimeStamp:  time.Now(),
+			StatusCode: c.Writer.Status(),
+			ClientIP:   c.ClientIP(),
+			Method:     c.Method,
+			Path:       path,
+			RawQuery:   rawQuery,
+			Proto:      c.Req.Proto,
+			BodySize:   c.Writer.Size(),
+			UserAgent:  c.Req.UserAgent(),
+			Referer:    c.Req.Referer(),
+			RequestID:  c.Req.Header.Get(requestIDHeader),
+		}
+		params.Latency = params.TimeStamp.Sub(start)
+		if params.BodySize < 0 {
+			params.BodySize = 0
+		}
+		if params.RequestID == "" {
+			params.RequestID = c.Writer.Header().Get(requestIDHeader)
+		}
+		if len(c.Errors) > 0 {
+			params.ErrorMessage = strings.Join(c.Errors.Errors(), "; ")
+		}
+
+		if config.Slog != nil {
+			logSlog(config.Slog, c, params)
+			return
+		}
+		line := formatter(params)
+		mu.Lock()
+		io.WriteString(out, line)
+		mu.Unlock()
+	}
+}
+
+// outputLocks 保存每个 Output 对应的锁，使共用同一个 Output 的多个 Logger 不会交错写入
+var outputLocks sync.Map // io.Writer -> *sync.Mutex
+
+// outputLock 返回 out 对应的锁，out 的类型不可比较（无法作为键）时返回一把独立的锁
+func outputLock(out io.Writer) *sync.Mutex {
+	if !reflect.TypeOf(out).Comparable() {
+		return new(sync.Mutex)
+	}
+	mu, _ := outputLocks.LoadOrStore(out, new(sync.Mutex))
+	return mu.(*sync.Mutex)
+}
+
+// TextLogFormatter 输出便于阅读的文本日志，例如
+//
+//	[GEE] 2023/07/01 - 12:00:00 | 200 |      1.2ms |       127.0.0.1 | GET      /hello?name=gee
+func TextLogFormatter(p LogParams) string {
+	line := fmt.Sprintf("[GEE] %s | %3d | %10v | %15s | %-8s %s",
+		p.TimeStamp.Format("2006/01/02 - 15:04:05"), p.StatusCode, p.Latency, p.ClientIP, p.Method, requestURI(p))
+	if p.ErrorMessage != "" {
+		line += " | " + p.ErrorMessage
+	}
+	return line + "\n"
+}
+
+// JSONLogFormatter 每条日志输出一行 JSON，便于日志系统采集
+func JSONLogFormatter(p LogParams) string {
+	entry := struct {
+		Time      string  `json:"time"`
+		Status    int     `json:"status"`
+		LatencyMs float64 `json:"latency_ms"`
+		ClientIP  string  `json:"client_ip"`
+		Method    string  `json:"method"`
+		Path      string  `json:"path"`
+		Query     string  `json:"query,omitempty"`
+		Proto     string  `json:"proto"`
+		Size      int     `json:"size"`
+		UserAgent string  `json:"user_agent,omitempty"`
+		Referer   string  `json:"referer,omitempty"`
+		RequestID string  `json:"request_id,omitempty"`
+		Error     string  `json:"error,omitempty"`
+	}{
+		Time:      p.TimeStamp.Format(time.RFC3339Nano),
+		Status:    p.StatusCode,
+		LatencyMs: float64(p.Latency) / float64(time.Millisecond),
+		ClientIP:  p.ClientIP,
+		Method:    p.Method,
+		Path:      p.Path,
+		Query:     p.RawQuery,
+		Proto:     p.Proto,
+		Size:      p.BodySize,
+		UserAgent: p.UserAgent,
+		Referer:   p.Referer,
+		RequestID: p.RequestID,
+		Error:     p.ErrorMessage,
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Sprintf("{\"error\":%q}\n", err.Error())
+	}
+	return string(data) + "\n"
+}
+
+// ApacheLogFormatter 输出 Apache combined 格式的日志，例如
+//
+//	127.0.0.1 - - [01/Jul/2023:12:00:00 +0800] "GET /hello HTTP/1.1" 200 13 "-" "curl/8.0"
+func ApacheLogFormatter(p LogParams) string {
+	size := "-"
+	if p.BodySize > 0 {
+		size = strconv.Itoa(p.BodySize)
+	}
+	return fmt.Sprintf("%s - - [%s] \"%s %s %s\" %d %s \"%s\" \"%s\"\n",
+		orDash(p.ClientIP), p.TimeStamp.Format("02/Jan/2006:15:04:05 -0700"), p.Method, requestURI(p), p.Proto,
+		p.StatusCode, size, orDash(p.Referer), orDash(p.UserAgent))
+}
+
+func requestURI(p LogParams) string {
+	if p.RawQuery == "" {
+		return p.Path
+	}
+	return p.Path + "?" + p.RawQuery
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func logSlog(logger *slog.Logger, c *Context, p LogParams) {
+	level := slog.LevelInfo
+	switch {
+	case p.StatusCode >= http.StatusInternalServerError:
+		level = slog.LevelError
+	case p.StatusCode >= http.StatusBadRequest:
+		level = slog.LevelWarn
+	}
+	attrs := []slog.Attr{
+		slog.Int("status", p.StatusCode),
+		slog.String("method", p.Method),
+		slog.String("path", p.Path),
+		slog.String("query", p.RawQuery),
+		slog.String("client_ip", p.ClientIP),
+		slog.Duration("latency", p.Latency),
+		slog.Int("size", p.BodySize),
+		slog.String("user_agent", p.UserAgent),
+	}
+	if p.RequestID != "" {
+		attrs = append(attrs, slog.String("request_id", p.RequestID))
+	}
+	if p.ErrorMessage != "" {
+		attrs = append(attrs, slog.String("error", p.ErrorMessage))
+	}
+	logger.LogAttrs(c.Req.Context(), level, "request", attrs...)
+}
@@ -0,0 +1,117 @@
+package gee
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"gee/internal/geetest"
+)
+
+func newLoggerTestEngine(config LoggerConfig) *Engine {
+	r := New()
+	r.Use(LoggerWithConfig(config))
+	r.GET("/hello", func(c *Context) {
+		c.String(http.StatusOK, "hello")
+	})
+	r.GET("/fail", func(c *Context) {
+		c.Error(errors.New("db down"))
+		c.Status(http.StatusServiceUnavailable)
+	})
+	r.GET("/healthz", func(c *Context) {})
+	return r
+}
+
+// loggerTestHeader 是日志测试请求带的头部，客户端地址为 httptest 默认的 192.0.2.1
+var loggerTestHeader = []string{"User-Agent", "gee-test", "Referer", "http://example.com/", "X-Request-ID", "req-1"}
+
+func TestLoggerJSON(t *testing.T) {
+	var buf bytes.Buffer
+	r := newLoggerTestEngine(LoggerConfig{Formatter: JSONLogFormatter, Output: &buf, SkipPaths: []string{"/healthz"}})
+	geetest.PerformRequest(r, "GET", "/hello?name=gee", loggerTestHeader...)
+	geetest.PerformRequest(r, "GET", "/healthz", loggerTestHeader...)
+	geetest.PerformRequest(r, "GET", "/fail", loggerTestHeader...)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %q", buf.String())
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"status": 200.0, "client_ip": "192.0.2.1", "method": "GET", "path": "/hello", "query": "name=gee",
+		"proto": "HTTP/1.1", "size": 5.0, "user_agent": "gee-test", "referer": "http://example.com/", "request_id": "req-1",
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("%s = %v, want %v", key, entry[key], value)
+		}
+	}
+	if _, ok := entry["latency_ms"].(float64); !ok {
+		t.Errorf("latency_ms should be a number, got %v", entry["latency_ms"])
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil || entry["status"] != 503.0 || entry["error"] != "db down" {
+		t.Errorf("unexpected entry %s", lines[1])
+	}
+}
+
+func TestLoggerFormats(t *testing.T) {
+	tests := []struct {
+		formatter LogFormatter
+		pattern   string
+	}{
+		{ApacheLogFormatter, `^192\.0\.2\.1 - - \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /hello\?a=1 HTTP/1\.1" 200 5 "http://example\.com/" "gee-test"\n$`},
+		{nil, `^\[GEE\] \d{4}/\d{2}/\d{2} - \d{2}:\d{2}:\d{2} \| 200 \| +\S+ \| +192\.0\.2\.1 \| GET +/hello\?a=1\n$`},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		geetest.PerformRequest(newLoggerTestEngine(LoggerConfig{Formatter: tt.formatter, Output: &buf}), "GET", "/hello?a=1", loggerTestHeader...)
+		if !regexp.MustCompile(tt.pattern).MatchString(buf.String()) {
+			t.Errorf("log line %q does not match %s", buf.String(), tt.pattern)
+		}
+	}
+}
+
+func TestLoggerSlog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	geetest.PerformRequest(newLoggerTestEngine(LoggerConfig{Slog: logger}), "GET", "/fail", loggerTestHeader...)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["level"] != "ERROR" || entry["msg"] != "request" || entry["status"] != 503.0 ||
+		entry["request_id"] != "req-1" || entry["error"] != "db down" {
+		t.Fatalf("unexpected slog entry %s", buf.String())
+	}
+}
+
+func TestLoggerConcurrentWrites(t *testing.T) {
+	// bytes.Buffer 不是并发安全的，在 -race 下可以发现没有加锁的写入
+	// 两个 Logger 共用同一个 Output，锁需要跨 Logger 生效
+	var buf bytes.Buffer
+	engines := []*Engine{newLoggerTestEngine(LoggerConfig{Output: &buf}), newLoggerTestEngine(LoggerConfig{Output: &buf})}
+
+	const n = 8
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(r *Engine) {
+			defer wg.Done()
+			geetest.PerformRequest(r, "GET", "/hello")
+		}(engines[i%len(engines)])
+	}
+	wg.Wait()
+	if lines := strings.Count(buf.String(), "\n"); lines != n {
+		t.Errorf("got %d log lines, want %d:\n%s", lines, n, buf.String())
+	}
+}
@@ -16,11 +16,16 @@ Go 语言还提供了 recover 函数，可以避免因为 panic 发生而导致
 package gee
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
+	"os"
 	"runtime"
 	"strings"
+	"syscall"
 )
 
 //用于打印堆栈跟踪信息以进行调试
@@ -30,10 +35,9 @@ import (
 2, 使用runtime.Callers函数获取当前调用栈的信息，并将调用栈的信息填充到pcs数组中。runtime.Callers的第一个参数是要跳过的调用层数，这里传递了3表示跳过前三层调用，以避免打印trace函数和recover函数自身的调用信息。
 3, 创建一个strings.Builder类型的变量str，用于构建最终的堆栈跟踪信息。
 4, 将传入的message添加到str中，并在其后添加字符串"\nTraceback:"，用于标识跟踪信息的开始。
-5, 遍历pcs数组中的地址，对每个地址执行以下操作：
-使用runtime.FuncForPC函数获取与给定程序计数器地址关联的函数。
-使用fn.FileLine函数获取函数对应的文件名和行号。
-使用fmt.Sprintf将文件名和行号格式化为字符串，并将其添加到str中。
+5, 使用runtime.CallersFrames把pcs中的地址解析为栈帧（内联的函数也会被展开），对每个栈帧执行以下操作：
+跳过runtime包内部的栈帧（如 runtime.gopanic），它们对定位问题没有帮助。
+将函数名、文件名和行号格式化为字符串，并将其添加到str中。
 6, 返回最终构建的堆栈跟踪信息，即str的字符串表示。
 */
 func trace(message string) string {
@@ -42,32 +46,101 @@ func trace(message string) string {
 
     var str strings.Builder
     str.WriteString(message + "\nTraceback:")
-    for _, pc := range pcs[:n] {
-        fn := runtime.FuncForPC(pc)
-        file, line := fn.FileLine(pc)
-		str.WriteString(fmt.Sprintf("\n\t%s:%d", file, line))
+    frames := runtime.CallersFrames(pcs[:n])
+    for {
+        frame, more := frames.Next()
+        if !strings.HasPrefix(frame.Function, "runtime.") {
+            fmt.Fprintf(&str, "\n\t%s\n\t\t%s:%d", frame.Function, frame.File, frame.Line)
+        }
+        if !more {
+            break
+        }
     }
     return str.String()
 }
 
 
-//用于处理恢复（recovery）的函数
+// RecoveryFunc 在处理函数 panic 后被调用，recovered 为 recover() 的返回值
+type RecoveryFunc func(c *Context, recovered interface{})
+
+//用于处理恢复（recovery）的函数，日志输出到标准错误，响应尚未写出时返回 500
 func Recovery() HandlerFunc {
-    return func(c *Context) {
-        defer func() {
-            //调用recover()函数捕获可能发生的panic异常
-            if err := recover(); err != nil {
-                message := fmt.Sprintf("%s", err)
-                log.Printf("%s\n\n", trace(message))
-                c.Fail(http.StatusInternalServerError, "Internal Server Error")
-            }
-        }()  //() 表示对匿名函数的立即调用
-        c.Next()
-    }
+    return RecoveryWithWriter(os.Stderr)
+}
+
+// RecoveryWithWriter 与 Recovery 相同，但日志输出到 out；可以传入一个 RecoveryFunc 代替默认的 500 响应
+func RecoveryWithWriter(out io.Writer, handle ...RecoveryFunc) HandlerFunc {
+	if len(handle) > 0 {
+		return CustomRecoveryWithWriter(out, handle[0])
+	}
+	return CustomRecoveryWithWriter(out, defaultHandleRecovery)
+}
+
+// CustomRecovery 在 panic 时调用 handle 生成响应，日志输出到标准错误
+func CustomRecovery(handle RecoveryFunc) HandlerFunc {
+	return CustomRecoveryWithWriter(os.Stderr, handle)
+}
+
+// CustomRecoveryWithWriter 在 panic 时把堆栈写入 out，并调用 handle 生成响应
+//   - panic 的值为 http.ErrAbortHandler 时继续 panic，由 net/http 静默地中断连接
+//   - 客户端已断开（broken pipe、connection reset）时只记录日志，不再写入响应
+func CustomRecoveryWithWriter(out io.Writer, handle RecoveryFunc) HandlerFunc {
+	logger := log.New(out, "", log.LstdFlags)
+	return func(c *Context) {
+		defer func() {
+			//调用recover()函数捕获可能发生的panic异常
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			err, _ := recovered.(error)
+			if err == nil {
+				err = fmt.Errorf("%v", recovered)
+			}
+			c.Error(err)
+			c.Abort()
+			if isBrokenPipe(err) {
+				logger.Printf("[Recovery] %s %s: client disconnected: %v\n", c.Method, c.Path, err)
+				return
+			}
+			logger.Printf("[Recovery] %s %s: %s\n\n", c.Method, c.Path, trace(fmt.Sprintf("%v", recovered)))
+			handle(c, recovered)
+		}() //() 表示对匿名函数的立即调用
+		c.Next()
+	}
+}
+
+//默认的恢复处理：响应尚未写出时返回 500，已经写出一部分时无法再修改状态码，直接结束
+func defaultHandleRecovery(c *Context, recovered interface{}) {
+	if !c.Writer.Written() {
+		c.Fail(http.StatusInternalServerError, "Internal Server Error")
+	}
+}
+
+//判断错误是否由客户端断开连接导致，此时向连接写入响应没有意义
+func isBrokenPipe(err error) bool {
+	if errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		msg := strings.ToLower(opErr.Err.Error())
+		return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+	}
+	return false
 }
 
 
 
+/*
+在没有错误恢复功能的情况下，一旦程序发生 panic 异常，它会打印出相关的错误信息和堆栈跟踪，然后立即退出。这种行为是为了确保错误被及时发现并尽早修复，以避免继续执行潜在有问题的程序。
+*/
+
+
+
 /*
 在没有错误恢复功能的情况下，一旦程序发生 panic 异常，它会打印出相关的错误信息和堆栈跟踪，然后立即退出。这种行为是为了确保错误被及时发现并尽早修复，以避免继续执行潜在有问题的程序。
 */
\ No newline at end of file
@@ -0,0 +1,82 @@
+package gee
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"gee/internal/geetest"
+)
+
+func newRecoveryTestEngine(recovery HandlerFunc) *Engine {
+	r := New()
+	r.Use(recovery)
+	r.GET("/panic", func(c *Context) {
+		panic("boom")
+	})
+	r.GET("/partial", func(c *Context) {
+		c.String(http.StatusOK, "partial")
+		panic("boom")
+	})
+	r.GET("/pipe", func(c *Context) {
+		panic(&net.OpError{Op: "write", Net: "tcp", Err: os.NewSyscallError("write", syscall.EPIPE)})
+	})
+	r.GET("/abort", func(c *Context) {
+		panic(http.ErrAbortHandler)
+	})
+	return r
+}
+
+func TestRecovery(t *testing.T) {
+	var logs bytes.Buffer
+	r := newRecoveryTestEngine(RecoveryWithWriter(&logs))
+
+	tests := []struct {
+		path string
+		code int
+		body string
+		log  string
+	}{
+		{"/panic", http.StatusInternalServerError, "{\"message\":\"Internal Server Error\"}\n", "recovery_test.go"},
+		{"/partial", http.StatusOK, "partial", "boom"},
+		{"/pipe", http.StatusOK, "", "client disconnected"},
+	}
+	for _, tt := range tests {
+		logs.Reset()
+		w := geetest.PerformRequest(r, "GET", tt.path)
+		if w.Code != tt.code || w.Body.String() != tt.body || !strings.Contains(logs.String(), tt.log) {
+			t.Errorf("%s: got %d %q, log %q", tt.path, w.Code, w.Body.String(), logs.String())
+		}
+	}
+
+	logs.Reset()
+	geetest.PerformRequest(r, "GET", "/panic")
+	if !strings.Contains(logs.String(), "gee.newRecoveryTestEngine.func1") || strings.Contains(logs.String(), "runtime.gopanic") {
+		t.Errorf("trace should contain function names and skip runtime frames:\n%s", logs.String())
+	}
+	if strings.Contains(logs.String(), "client disconnected") {
+		t.Error("regular panics must not be treated as broken pipes")
+	}
+
+	defer func() {
+		if recover() != http.ErrAbortHandler {
+			t.Error("http.ErrAbortHandler should be re-panicked")
+		}
+	}()
+	geetest.PerformRequest(r, "GET", "/abort")
+}
+
+func TestCustomRecovery(t *testing.T) {
+	var logs bytes.Buffer
+	r := newRecoveryTestEngine(CustomRecoveryWithWriter(&logs, func(c *Context, recovered interface{}) {
+		c.String(http.StatusServiceUnavailable, "recovered: %v", recovered)
+	}))
+	w := geetest.PerformRequest(r, "GET", "/panic")
+	if w.Code != http.StatusServiceUnavailable || w.Body.String() != "recovered: boom" {
+		t.Fatalf("got %d %q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,297 @@
+package gee
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+响应渲染：每种格式先设置正确的 Content-Type，再写入状态码和内容
+序列化在写入状态码之前完成，失败时返回 500，而不是在已经写出 200 之后再追加错误信息
+*/
+
+const (
+	MIMEHTML     = "text/html"
+	MIMEPlain    = "text/plain"
+	MIMEYAML     = "application/x-yaml"
+	MIMEProtoBuf = "application/x-protobuf"
+	MIMEJSONP    = "application/javascript"
+)
+
+const defaultSecureJSONPrefix = "while(1);"
+
+// writeBody 设置 Content-Type、写入状态码和 body
+func (c *Context) writeBody(code int, contentType string, body []byte) {
+	c.SetHeader("Content-Type", contentType)
+	c.Status(code)
+	c.Writer.Write(body)
+}
+
+// renderError 序列化失败时记录错误并返回 500
+func (c *Context) renderError(err error) {
+	c.Error(err).SetType(ErrorTypeRender)
+	c.writeBody(http.StatusInternalServerError, MIMEPlain+"; charset=utf-8", []byte(err.Error()))
+}
+
+// IndentedJSON 返回缩进格式的 JSON，便于阅读，但会增加响应体积
+func (c *Context) IndentedJSON(code int, obj interface{}) {
+	data, err := json.MarshalIndent(obj, "", "    ")
+	if err != nil {
+		c.renderError(err)
+		return
+	}
+	c.writeBody(code, MIMEJSON+"; charset=utf-8", data)
+}
+
+// SecureJSON 在 JSON 数组前加上前缀（默认为 while(1);），防止 JSON 劫持
+// 前缀可以通过 Engine.SecureJSONPrefix 修改
+func (c *Context) SecureJSON(code int, obj interface{}) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		c.renderError(err)
+		return
+	}
+	if bytes.HasPrefix(data, []byte("[")) {
+		data = append([]byte(c.engine.secureJSONPrefix), data...)
+	}
+	c.writeBody(code, MIMEJSON+"; charset=utf-8", data)
+}
+
+// PureJSON 与 JSON 相同，但不会把 <、>、& 等 HTML 字符转义为 \u003c 的形式
+func (c *Context) PureJSON(code int, obj interface{}) {
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(obj); err != nil {
+		c.renderError(err)
+		return
+	}
+	c.writeBody(code, MIMEJSON+"; charset=utf-8", buf.Bytes())
+}
+
+// jsonpCallback 匹配合法的回调名：JavaScript 标识符，或以 . 连接的成员路径（如 jQuery.cb）
+var jsonpCallback = regexp.MustCompile(`^[A-Za-z_$][\w$]*(\.[A-Za-z_$][\w$]*)*$`)
+
+// JSONP 以查询参数 callback 指定的函数名包装 JSON，没有 callback 时与 JSON 相同
+// callback 由客户端控制，只接受标识符或成员路径，否则返回 400，避免把任意脚本回显给浏览器执行
+func (c *Context) JSONP(code int, obj interface{}) {
+	callback := c.Query("callback")
+	if callback == "" {
+		c.JSON(code, obj)
+		return
+	}
+	if !jsonpCallback.MatchString(callback) {
+		c.Error(fmt.Errorf("gee: invalid JSONP callback %q", callback)).SetType(ErrorTypePublic)
+		c.Fail(http.StatusBadRequest, "invalid JSONP callback")
+		return
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		c.renderError(err)
+		return
+	}
+	buf := new(bytes.Buffer)
+	buf.WriteString(callback)
+	buf.WriteByte('(')
+	buf.Write(data)
+	buf.WriteString(");")
+	c.writeBody(code, MIMEJSONP+"; charset=utf-8", buf.Bytes())
+}
+
+// XML 返回 XML 格式的响应，H 会被编码为以 map 为根元素、键为子元素的文档
+func (c *Context) XML(code int, obj interface{}) {
+	buf := new(bytes.Buffer)
+	buf.WriteString(xml.Header)
+	if err := xml.NewEncoder(buf).Encode(obj); err != nil {
+		c.renderError(err)
+		return
+	}
+	c.writeBody(code, MIMEXML+"; charset=utf-8", buf.Bytes())
+}
+
+// MarshalXML 让 H 可以直接作为 XML 输出，键按字典序排列
+func (h H) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Local: "map"}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(h))
+	for key := range h {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := e.EncodeElement(h[key], xml.StartElement{Name: xml.Name{Local: key}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(xml.EndElement{Name: start.Name})
+}
+
+// YAML 返回 YAML 格式的响应
+func (c *Context) YAML(code int, obj interface{}) {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		c.renderError(err)
+		return
+	}
+	c.writeBody(code, MIMEYAML+"; charset=utf-8", data)
+}
+
+// ProtoBuf 返回 Protocol Buffers 编码的响应，obj 必须实现 proto.Message
+func (c *Context) ProtoBuf(code int, obj interface{}) {
+	msg, ok := obj.(proto.Message)
+	if !ok {
+		c.renderError(fmt.Errorf("gee: %T is not a proto.Message", obj))
+		return
+	}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		c.renderError(err)
+		return
+	}
+	c.writeBody(code, MIMEProtoBuf, data)
+}
+
+// File 返回文件内容，支持 Range 与 If-Modified-Since 等条件请求
+func (c *Context) File(path string) {
+	http.ServeFile(c.Writer, c.Req, path)
+}
+
+// FileAttachment 以附件的形式返回文件，浏览器会以 filename 为文件名下载
+func (c *Context) FileAttachment(path string, filename string) {
+	if filename == "" {
+		filename = filepath.Base(path)
+	}
+	c.SetHeader("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	http.ServeFile(c.Writer, c.Req, path)
+}
+
+// Redirect 重定向到 location，code 必须是 3xx 或 201 Created
+func (c *Context) Redirect(code int, location string) {
+	if (code < http.StatusMultipleChoices || code > http.StatusPermanentRedirect) && code != http.StatusCreated {
+		panic(fmt.Sprintf("gee: cannot redirect with status code %d", code))
+	}
+	c.StatusCode = code
+	http.Redirect(c.Writer, c.Req, location, code)
+}
+
+// Negotiate 描述可以提供的响应格式及各格式对应的数据
+// 某种格式没有单独指定数据时使用 Data
+type Negotiate struct {
+	Offered  []string // 可以提供的 MIME 类型，按优先级排列
+	HTMLName string
+	HTMLData interface{}
+	JSONData interface{}
+	XMLData  interface{}
+	YAMLData interface{}
+	Data     interface{}
+}
+
+func (n Negotiate) dataFor(specific interface{}) interface{} {
+	if specific != nil {
+		return specific
+	}
+	return n.Data
+}
+
+// Negotiate 根据 Accept 头部从 config.Offered 中选择响应格式，没有可接受的格式时返回 406
+func (c *Context) Negotiate(code int, config Negotiate) {
+	switch c.NegotiateFormat(config.Offered...) {
+	case MIMEJSON:
+		c.JSON(code, config.dataFor(config.JSONData))
+	case MIMEXML, MIMEXML2:
+		c.XML(code, config.dataFor(config.XMLData))
+	case MIMEYAML:
+		c.YAML(code, config.dataFor(config.YAMLData))
+	case MIMEHTML:
+		c.HTML(code, config.HTMLName, config.dataFor(config.HTMLData))
+	default:
+		c.Abort()
+		c.writeBody(http.StatusNotAcceptable, MIMEPlain+"; charset=utf-8", []byte("406 NOT ACCEPTABLE\n"))
+	}
+}
+
+// acceptRange 是 Accept 头部中的一项
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// NegotiateFormat 返回 offered 中客户端最能接受的 MIME 类型
+// 按 Accept 中的 q 值从高到低匹配，支持 text/* 与 */* 通配；
+// q=0 表示明确拒绝该类型；没有 Accept 头部时返回 offered[0]，没有可接受的类型时返回空字符串
+func (c *Context) NegotiateFormat(offered ...string) string {
+	if len(offered) == 0 {
+		return ""
+	}
+	accept := c.Req.Header.Get("Accept")
+	if accept == "" {
+		return offered[0]
+	}
+	ranges := parseAccept(accept)
+	for _, r := range ranges {
+		if r.q <= 0 {
+			continue
+		}
+		for _, offer := range offered {
+			if matchMediaType(r.mediaType, offer) && !refused(ranges, offer) {
+				return offer
+			}
+		}
+	}
+	return ""
+}
+
+// refused 判断 Accept 中是否以 q=0 明确拒绝了 offer，例如 application/json;q=0, */*
+func refused(ranges []acceptRange, offer string) bool {
+	for _, r := range ranges {
+		if r.q <= 0 && r.mediaType == offer {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAccept 解析 Accept 头部，按 q 值从高到低排序，q 值相同时保持原有顺序
+func parseAccept(accept string) []acceptRange {
+	ranges := make([]acceptRange, 0)
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, q: q})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	return ranges
+}
+
+// matchMediaType 判断 Accept 中的 accepted（可能带通配符）是否包含 offer
+func matchMediaType(accepted, offer string) bool {
+	if accepted == "*/*" || accepted == offer {
+		return true
+	}
+	if strings.HasSuffix(accepted, "/*") {
+		return strings.HasPrefix(offer, accepted[:len(accepted)-1])
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"gee/internal/geetest"
+)
+
+func render(handler HandlerFunc, target string, header ...string) *httptest.ResponseRecorder {
+	r := New()
+	r.GET("/render", handler)
+	return geetest.PerformRequest(r, "GET", target, header...)
+}
+
+func TestRenderers(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		handler     HandlerFunc
+		contentType string
+		body        string
+	}{
+		{"JSON", "/render", func(c *Context) { c.JSON(200, H{"a": "<b>"}) },
+			"application/json; charset=utf-8", "{\"a\":\"\\u003cb\\u003e\"}\n"},
+		{"PureJSON", "/render", func(c *Context) { c.PureJSON(200, H{"a": "<b>"}) },
+			"application/json; charset=utf-8", "{\"a\":\"<b>\"}\n"},
+		{"IndentedJSON", "/render", func(c *Context) { c.IndentedJSON(200, H{"a": 1}) },
+			"application/json; charset=utf-8", "{\n    \"a\": 1\n}"},
+		{"SecureJSON", "/render", func(c *Context) { c.SecureJSON(200, []int{1, 2}) },
+			"application/json; charset=utf-8", "while(1);[1,2]"},
+		{"JSONP", "/render?callback=cb", func(c *Context) { c.JSONP(200, H{"a": 1}) },
+			"application/javascript; charset=utf-8", "cb({\"a\":1});"},
+		{"XML", "/render", func(c *Context) { c.XML(200, H{"b": 2, "a": "x"}) },
+			"application/xml; charset=utf-8", xmlHeader + "<map><a>x</a><b>2</b></map>"},
+		{"YAML", "/render", func(c *Context) { c.YAML(200, H{"a": 1}) },
+			"application/x-yaml; charset=utf-8", "a: 1\n"},
+		{"String", "/render", func(c *Context) { c.String(200, "hi %s", "gee") },
+			"text/plain; charset=utf-8", "hi gee"},
+	}
+	for _, tt := range tests {
+		w := render(tt.handler, tt.target)
+		if ct := w.Header().Get("Content-Type"); ct != tt.contentType {
+			t.Errorf("%s: Content-Type = %q, want %q", tt.name, ct, tt.contentType)
+		}
+		if w.Body.String() != tt.body {
+			t.Errorf("%s: body = %q, want %q", tt.name, w.Body.String(), tt.body)
+		}
+	}
+}
+
+func TestJSONPCallback(t *testing.T) {
+	handler := func(c *Context) { c.JSONP(200, H{"a": 1}) }
+	for _, callback := range []string{"cb", "jQuery_1.handle", "$.cb"} {
+		w := render(handler, "/render?callback="+url.QueryEscape(callback))
+		if w.Code != http.StatusOK || w.Body.String() != callback+`({"a":1});` {
+			t.Errorf("callback %q: got %d %q", callback, w.Code, w.Body.String())
+		}
+	}
+	for _, callback := range []string{"alert(document.domain);x", "cb//", "a.", "1cb", "cb<script>", "a..b"} {
+		w := render(handler, "/render?callback="+url.QueryEscape(callback))
+		if w.Code != http.StatusBadRequest || strings.Contains(w.Body.String(), callback) {
+			t.Errorf("callback %q: got %d %q, want 400 without echoing the callback", callback, w.Code, w.Body.String())
+		}
+	}
+}
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+
+func TestProtoBuf(t *testing.T) {
+	w := render(func(c *Context) { c.ProtoBuf(200, wrapperspb.String("gee")) }, "/render")
+	var got wrapperspb.StringValue
+	if err := proto.Unmarshal(w.Body.Bytes(), &got); err != nil || got.Value != "gee" {
+		t.Fatalf("unexpected protobuf body %q: %v", w.Body.Bytes(), err)
+	}
+	if w := render(func(c *Context) { c.ProtoBuf(200, H{}) }, "/render"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("non proto.Message should fail with 500, got %d", w.Code)
+	}
+}
+
+func TestFileAndRedirect(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "report.txt")
+	if err := os.WriteFile(name, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w := render(func(c *Context) { c.FileAttachment(name, "报告.txt") }, "/render")
+	if w.Body.String() != "content" || w.Header().Get("Content-Disposition") != "attachment; filename*=utf-8''%E6%8A%A5%E5%91%8A.txt" {
+		t.Fatalf("unexpected attachment %q, %q", w.Body.String(), w.Header().Get("Content-Disposition"))
+	}
+
+	w = render(func(c *Context) { c.Redirect(http.StatusFound, "/login") }, "/render")
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "/login" {
+		t.Fatalf("unexpected redirect %d %q", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	handler := func(c *Context) {
+		c.Negotiate(200, Negotiate{Offered: []string{MIMEJSON, MIMEXML}, Data: H{"a": 1}})
+	}
+	tests := []struct {
+		accept      string
+		code        int
+		contentType string
+	}{
+		{"", 200, "application/json; charset=utf-8"},
+		{"application/xml", 200, "application/xml; charset=utf-8"},
+		{"text/html, application/xml;q=0.9, */*;q=0.8", 200, "application/xml; charset=utf-8"},
+		{"application/json;q=0.5, application/xml", 200, "application/xml; charset=utf-8"},
+		{"application/*", 200, "application/json; charset=utf-8"},
+		{"text/html", http.StatusNotAcceptable, "text/plain; charset=utf-8"},
+		{"application/json;q=0, */*", 200, "application/xml; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		w := render(handler, "/render", "Accept", tt.accept)
+		if w.Code != tt.code || w.Header().Get("Content-Type") != tt.contentType {
+			t.Errorf("Accept %q: got %d %q, want %d %q", tt.accept, w.Code, w.Header().Get("Content-Type"), tt.code, tt.contentType)
+		}
+	}
+}
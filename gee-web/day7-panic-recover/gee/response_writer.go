@@ -0,0 +1,146 @@
+package gee
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+)
+
+/*
+ResponseWriter 包装 http.ResponseWriter，记录响应的状态码与已写入的字节数。
+状态码不会在 WriteHeader 时立即写出，而是推迟到第一次写入响应体（或处理链结束）时，
+因此在写出之前可以多次修改状态码；写出之后再修改只会打印警告，不会再次写入响应头。
+无论处理函数通过 c.Status 还是直接调用 c.Writer，Logger 等中间件都能拿到真实的状态码。
+*/
+
+const noWritten = -1
+
+// ResponseWriter 是 Context.Writer 的类型
+type ResponseWriter interface {
+	http.ResponseWriter
+	http.Hijacker
+	http.Flusher
+	http.Pusher
+
+	// Status 返回响应的状态码，未设置时为 200
+	Status() int
+	// Size 返回已写入响应体的字节数，尚未写出响应头时为 -1
+	Size() int
+	// Written 返回响应头是否已经写出
+	Written() bool
+	// WriteHeaderNow 立即写出响应头
+	WriteHeaderNow()
+	// Pusher 返回用于 HTTP/2 服务端推送的 http.Pusher，底层连接不支持时返回 nil
+	Pusher() http.Pusher
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	size        int
+	status      int
+	discardBody bool // HEAD 请求回退到 GET 处理函数时，只保留响应头，丢弃响应体
+}
+
+var _ ResponseWriter = (*responseWriter)(nil)
+
+func (w *responseWriter) reset(writer http.ResponseWriter) {
+	w.ResponseWriter = writer
+	w.size = noWritten
+	w.status = http.StatusOK
+	w.discardBody = false
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if code <= 0 || code == w.status {
+		return
+	}
+	if w.Written() {
+		log.Printf("[WARNING] Headers were already written. Wanted to override status code %d with %d", w.status, code)
+		return
+	}
+	w.status = code
+}
+
+func (w *responseWriter) WriteHeaderNow() {
+	if !w.Written() {
+		w.size = 0
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+func (w *responseWriter) Write(data []byte) (n int, err error) {
+	w.WriteHeaderNow()
+	if w.discardBody {
+		n = len(data)
+	} else {
+		n, err = w.ResponseWriter.Write(data)
+	}
+	w.size += n
+	return
+}
+
+func (w *responseWriter) WriteString(s string) (n int, err error) {
+	w.WriteHeaderNow()
+	if w.discardBody {
+		n = len(s)
+	} else {
+		n, err = io.WriteString(w.ResponseWriter, s)
+	}
+	w.size += n
+	return
+}
+
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+func (w *responseWriter) Size() int {
+	return w.size
+}
+
+func (w *responseWriter) Written() bool {
+	return w.size != noWritten
+}
+
+// Hijack 接管底层连接，之后响应由调用方自行写入，不再由 gee 写出响应头
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("gee: the ResponseWriter doesn't support the Hijacker interface")
+	}
+	if w.size < 0 {
+		w.size = 0
+	}
+	return hijacker.Hijack()
+}
+
+// Flush 写出响应头，并把已缓冲的数据发送给客户端
+func (w *responseWriter) Flush() {
+	w.WriteHeaderNow()
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *responseWriter) Pusher() http.Pusher {
+	if pusher, ok := w.ResponseWriter.(http.Pusher); ok {
+		return pusher
+	}
+	return nil
+}
+
+// Push 实现 http.Pusher，交给底层连接发起 HTTP/2 服务端推送，不支持时返回 http.ErrNotSupported
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher := w.Pusher(); pusher != nil {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap 返回被包装的 http.ResponseWriter，供 http.ResponseController 使用
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,126 @@
+package gee
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseWriterTracksStatus(t *testing.T) {
+	var status, size int
+	r := New()
+	r.Use(func(c *Context) {
+		c.Next()
+		status, size = c.Writer.Status(), c.Writer.Size()
+	})
+	r.GET("/direct", func(c *Context) {
+		c.Writer.WriteHeader(http.StatusAccepted)
+		c.Writer.Write([]byte("hello"))
+	})
+	r.GET("/nocontent", func(c *Context) {
+		c.Status(http.StatusNoContent)
+	})
+	r.GET("/twice", func(c *Context) {
+		c.String(http.StatusOK, "partial")
+		c.Fail(http.StatusInternalServerError, "too late")
+	})
+
+	tests := []struct {
+		path       string
+		code, size int
+	}{
+		{"/direct", http.StatusAccepted, 5},
+		{"/nocontent", http.StatusNoContent, -1},
+		{"/twice", http.StatusOK, len("partial") + len(`{"message":"too late"}`+"\n")},
+		{"/missing", http.StatusNotFound, len("404 NOT FOUND: /missing\n")},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.code || status != tt.code || size != tt.size {
+			t.Errorf("%s: got code=%d status=%d size=%d, want %d %d", tt.path, w.Code, status, size, tt.code, tt.size)
+		}
+	}
+}
+
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked, wroteHeader bool
+}
+
+func (w *hijackRecorder) WriteHeader(code int) {
+	w.wroteHeader = true
+	w.ResponseRecorder.WriteHeader(code)
+}
+
+func (w *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked = true
+	return nil, nil, nil
+}
+
+func TestResponseWriterPassthrough(t *testing.T) {
+	r := New()
+	r.GET("/hijack", func(c *Context) {
+		c.Writer.Hijack()
+	})
+	r.GET("/flush", func(c *Context) {
+		c.Status(http.StatusCreated)
+		c.Writer.Flush()
+		if c.Writer.Pusher() != nil {
+			t.Error("httptest.ResponseRecorder does not support push")
+		}
+	})
+
+	w := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/hijack", nil))
+	if !w.hijacked {
+		t.Fatal("expected hijack to be passed through")
+	}
+	if w.wroteHeader {
+		t.Fatal("headers must not be written after hijacking")
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/flush", nil))
+	if !rec.Flushed || rec.Code != http.StatusCreated {
+		t.Fatalf("expected flushed 201, got flushed=%v code=%d", rec.Flushed, rec.Code)
+	}
+}
+
+// pushRecorder 模拟支持 HTTP/2 服务端推送的 ResponseWriter
+type pushRecorder struct {
+	*httptest.ResponseRecorder
+	pushed []string
+}
+
+func (w *pushRecorder) Push(target string, opts *http.PushOptions) error {
+	w.pushed = append(w.pushed, target)
+	return nil
+}
+
+func TestResponseWriterPush(t *testing.T) {
+	r := New()
+	var pushErr error
+	r.GET("/", func(c *Context) {
+		pusher, ok := c.Writer.(http.Pusher)
+		if !ok {
+			t.Fatal("c.Writer should implement http.Pusher")
+		}
+		pushErr = pusher.Push("/static/app.js", nil)
+		c.String(http.StatusOK, "ok")
+	})
+
+	w := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if pushErr != nil || len(w.pushed) != 1 || w.pushed[0] != "/static/app.js" {
+		t.Fatalf("expected push to be passed through, got %v, %v", w.pushed, pushErr)
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if !errors.Is(pushErr, http.ErrNotSupported) {
+		t.Fatalf("expected http.ErrNotSupported, got %v", pushErr)
+	}
+}
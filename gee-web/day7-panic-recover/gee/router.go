@@ -2,20 +2,27 @@ package gee
 
 import (
     "net/http"
+    "sort"
     "strings"
 )
 
-//为每种请求方式都创建一个独立的 Trie 树用于路由匹配
-//用 roots 来存储每种请求方式（如 GET、POST、PUT 等）的 Trie 树的根节点
+//Any 注册的请求方法
+var anyMethods = []string{
+    http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+    http.MethodHead, http.MethodOptions, http.MethodDelete,
+    http.MethodConnect, http.MethodTrace,
+}
+
+//为每种请求方式都创建一个独立的基数树用于路由匹配
+//用 roots 来存储每种请求方式（如 GET、POST、PUT 等）的基数树的根节点，处理函数存储在节点上
 type router struct {
     roots map[string]*node
-    handlers map[string]HandlerFunc
+    maxParams int //所有路由中参数个数的最大值，用于预先分配 Params 的容量
 }
 
 func newRouter() *router {
     return &router{
         roots: make(map[string]*node),
-        handlers: make(map[string]HandlerFunc),
     }
 }
 
@@ -35,11 +42,25 @@ func parsePattern(pattern string) []string {
     return parts
 }
 
+//检查路由模式是否合法：通配符必须有名字（*catchall 除外），*catchall 只能位于最后一段
+func validatePattern(pattern string) {
+    segments := strings.Split(pattern, "/")
+    for i, seg := range segments {
+        if seg == ":" {
+            panic("gee: wildcards must be named with a non-empty name in pattern '" + pattern + "'")
+        }
+        if seg != "" && seg[0] == '*' && i != len(segments)-1 {
+            panic("gee: catch-all wildcard '" + seg + "' is only allowed at the end of pattern '" + pattern + "'")
+        }
+    }
+}
+
 //向router添加路由规则
-func (r *router) addRoute(method string, pattern string, handler HandlerFunc) {
-    parts := parsePattern(pattern)
+//路由模式中连续或结尾的 "/" 会被忽略，例如 /hello/ 与 /hello 是同一条路由
+func (r *router) addRoute(method string, pattern string, handlers []HandlerFunc) {
+    validatePattern(pattern)
+    cleaned := "/" + strings.Join(parsePattern(pattern), "/")
 
-    key := method + "-" + pattern
     //先检查请求方法是否存在
     _, ok := r.roots[method]
     if !ok {
@@ -47,46 +68,24 @@ func (r *router) addRoute(method string, pattern string, handler HandlerFunc) {
         r.roots[method] = &node{}
     }
     //调用根节点的 insert 方法
-    r.roots[method].insert(pattern, parts, 0)
-    r.handlers[key] = handler
+    if n := r.roots[method].insert(pattern, cleaned, handlers); n > r.maxParams {
+        r.maxParams = n
+    }
 }
 
 //根据请求方法和路径查找匹配的路由规则
-//返回匹配到的节点（*node）和解析得到的参数（map[string]string）
-//如果没有通配符，返回的 参数映射 就为空
-func (r *router) getRoute(method string, path string) (*node, map[string]string) {
-    searchParts := parsePattern(path)
-    //创建存储解析得到的参数的切片
-    params := make(map[string]string)
+//返回匹配到的节点（*node），解析得到的参数追加到 params 中
+//params 由调用方提供并复用，查找过程不分配内存
+func (r *router) getRoute(method string, path string, params *Params) *node {
     root, ok := r.roots[method]
-
     if !ok {
-        return nil, nil
+        return nil
     }
-
-    n := root.search(searchParts, 0)
-
-    if n != nil {
-        parts := parsePattern(n.pattern)
-        for index, part := range parts {
-            if part[0] == ':' {
-                //将通配符参数作为键，路径中的实际值作为值
-                params[part[1:]] = searchParts[index]
-            }
-            /*
-            如果遇到了以 * 开头的部分且长度大于 1，就将该部分的名称（去除了 *）作为键，将路径部分切片 searchParts 中当前位置到结尾的所有值用斜杠 / 连接起来作为值，存储到参数映射 params 中。
-
-            例如，对于路由模式 /static/*filepath 和路径 /static/css/geektutu.css，当遇到 *filepath 时，params["filepath"] 将被赋值为 "css/geektutu.css"。这样，处理函数就可以通过访问 params["filepath"] 来获取匹配到的路径部分。
-            */
-            if part[0] == '*' && len(part) > 1 {
-                params[part[1:]] = strings.Join(searchParts[index:], "/")
-                break
-            }
-        }
-        return n, params
+    //与注册时一致，忽略结尾的 "/"
+    if len(path) > 1 && path[len(path)-1] == '/' {
+        path = path[:len(path)-1]
     }
-
-    return nil, nil
+    return root.search(path, params)
 }
 
 //用于获取指定请求方法下的所有路由节点
@@ -101,33 +100,82 @@ func (r *router) getRoutes(method string) []*node {
     return nodes
 }
 
+//计算路径 path 在除 method 以外的其他请求方法下是否存在路由，返回排好序的 Allow 列表
+//注册了 GET 即隐含支持 HEAD，OPTIONS 总是自动支持
+func (r *router) allowed(method string, path string) []string {
+    allow := make([]string, 0)
+    for m := range r.roots {
+        if m == method || m == http.MethodOptions {
+            continue
+        }
+        var params Params
+        if r.getRoute(m, path, &params) != nil {
+            allow = append(allow, m)
+        }
+    }
+    if len(allow) == 0 {
+        return nil
+    }
+    if contains(allow, http.MethodGet) && !contains(allow, http.MethodHead) {
+        allow = append(allow, http.MethodHead)
+    }
+    allow = append(allow, http.MethodOptions)
+    sort.Strings(allow)
+    return allow
+}
+
+func contains(list []string, s string) bool {
+    for _, item := range list {
+        if item == s {
+            return true
+        }
+    }
+    return false
+}
+
 //处理路由请求
 func (r *router) handle(c *Context) {
-    n, params := r.getRoute(c.Method, c.Path)
+    //参数直接写入 Context 复用的 Params 中
+    n := r.getRoute(c.Method, c.Path, &c.Params)
+    //HEAD 没有单独注册时，回退到 GET 的处理函数
+    if n == nil && c.Method == http.MethodHead {
+        if n = r.getRoute(http.MethodGet, c.Path, &c.Params); n != nil {
+            c.writermem.discardBody = true
+        }
+    }
 
     if n != nil {
-        c.Params = params
-        //构建处理函数的键 key，用于在 handlers 映射中查找对应的处理函数
-        key := c.Method + "-" + n.pattern
-        //r.handlers[key](c)
-
-        //将与路由节点匹配的处理函数 r.handlers[key] 添加到上下文对象 c 的处理函数链 handlers 中
-        c.handlers = append(c.handlers, r.handlers[key])
+        //直接使用注册时计算好的处理链，处理链是共享的，不能再向其中 append
+        c.handlers = n.handlers
+    } else if allow := r.allowed(c.Method, c.Path); allow != nil {
+        //路径在其他请求方法下存在：OPTIONS 自动应答，其余返回 405，两者都带上 Allow 头部
+        c.SetHeader("Allow", strings.Join(allow, ", "))
+        if c.Method == http.MethodOptions {
+            c.handlers = c.engine.allOptions
+        } else {
+            c.handlers = c.engine.allNoMethod
+        }
     } else {
-        /*
-        如果路由节点 n 为空，表示未找到匹配的路由，那么将添加一个默认的处理函数到上下文对象 c 的处理函数链中。
-        这个默认的处理函数返回一个包含 "404 NOT FOUND" 错误信息的字符串响应。*/
-        c.handlers = append(c.handlers, func(c *Context) {
-            c.String(http.StatusNotFound, "404 NOT FOUND: %s\n", c.Path)
-        })
+        c.handlers = c.engine.allNoRoute
     }
     c.Next()  //这里调用Next函数，相当于是整个中间件链的入口
     /*
-    与之前直接调用路由处理函数不同的是
-    在添加中间件功能后，
-    收到路由请求后，ServeHTTP函数先根据前缀添加 会用到的中间件 到上下文里，
-    然后调用handle路由处理函数，handle函数在映射中查找对应的处理函数
-    并将处理函数添加到中间件链中
-    最后调用Next函数，开始执行上下文的中间件链
+    匹配到路由时，处理链（分组中间件 + 路由中间件 + 处理函数）在注册路由时就已经计算好，直接执行即可；
+    未匹配到路由时，执行的是 全局中间件 + 404/405/OPTIONS 处理函数 的处理链，不会执行任何分组的中间件
     */
-}
\ No newline at end of file
+}
+
+//默认的 404 处理函数
+func serveNotFound(c *Context) {
+    c.String(http.StatusNotFound, "404 NOT FOUND: %s\n", c.Path)
+}
+
+//默认的 405 处理函数
+func serveMethodNotAllowed(c *Context) {
+    c.String(http.StatusMethodNotAllowed, "405 METHOD NOT ALLOWED: %s %s\n", c.Method, c.Path)
+}
+
+//自动应答 OPTIONS 请求，Allow 头部已经在 handle 中设置
+func serveOptions(c *Context) {
+    c.Status(http.StatusNoContent)
+}
@@ -16,6 +16,17 @@ func newTestRouter() *router {
 	return r
 }
 
+// getRoute 查找路由并把参数转换为 map，便于断言
+func getRoute(r *router, method string, path string) (*node, map[string]string) {
+	var params Params
+	n := r.getRoute(method, path, &params)
+	ps := make(map[string]string)
+	for _, p := range params {
+		ps[p.Key] = p.Value
+	}
+	return n, ps
+}
+
 func TestParsePattern(t *testing.T) {
 	ok := reflect.DeepEqual(parsePattern("/p/:name"), []string{"p", ":name"})
 	ok = ok && reflect.DeepEqual(parsePattern("/p/*"), []string{"p", "*"})
@@ -27,7 +38,7 @@ func TestParsePattern(t *testing.T) {
 
 func TestGetRoute(t *testing.T) {
 	r := newTestRouter()
-	n, ps := r.getRoute("GET", "/hello/geektutu")
+	n, ps := getRoute(r, "GET", "/hello/geektutu")
 
 	if n == nil {
 		t.Fatal("nil shouldn't be returned")
@@ -47,13 +58,13 @@ func TestGetRoute(t *testing.T) {
 
 func TestGetRoute2(t *testing.T) {
 	r := newTestRouter()
-	n1, ps1 := r.getRoute("GET", "/assets/file1.txt")
+	n1, ps1 := getRoute(r, "GET", "/assets/file1.txt")
 	ok1 := n1.pattern == "/assets/*filepath" && ps1["filepath"] == "file1.txt"
 	if !ok1 {
 		t.Fatal("pattern shoule be /assets/*filepath & filepath shoule be file1.txt")
 	}
 
-	n2, ps2 := r.getRoute("GET", "/assets/css/test.css")
+	n2, ps2 := getRoute(r, "GET", "/assets/css/test.css")
 	ok2 := n2.pattern == "/assets/*filepath" && ps2["filepath"] == "css/test.css"
 	if !ok2 {
 		t.Fatal("pattern shoule be /assets/*filepath & filepath shoule be css/test.css")
@@ -71,4 +82,150 @@ func TestGetRoutes(t *testing.T) {
 	if len(nodes) != 5 {
 		t.Fatal("the number of routes shoule be 4")
 	}
-}
\ No newline at end of file
+}
+
+func TestAddRouteConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		panicMsg string
+	}{
+		{"duplicate", []string{"/hello/:name", "/hello/:name"},
+			"gee: pattern '/hello/:name' conflicts with existing pattern '/hello/:name'"},
+		{"trailing slash duplicate", []string{"/hello", "/hello/"},
+			"gee: pattern '/hello/' conflicts with existing pattern '/hello'"},
+		{"param names", []string{"/hello/:name", "/hello/:id"},
+			"gee: wildcard ':id' in pattern '/hello/:id' conflicts with existing wildcard ':name' in '/hello/:name'"},
+		{"nested param names", []string{"/a/:x/b", "/a/:y/c"},
+			"gee: wildcard ':y' in pattern '/a/:y/c' conflicts with existing wildcard ':x' in '/a/:x'"},
+		{"catch-all names", []string{"/assets/*filepath", "/assets/*path"},
+			"gee: wildcard '*path' in pattern '/assets/*path' conflicts with existing wildcard '*filepath' in '/assets/*filepath'"},
+		{"unnamed param", []string{"/hello/:"},
+			"gee: wildcards must be named with a non-empty name in pattern '/hello/:'"},
+		{"catch-all not at end", []string{"/assets/*filepath/x"},
+			"gee: catch-all wildcard '*filepath' is only allowed at the end of pattern '/assets/*filepath/x'"},
+		{"static, param and catch-all siblings", []string{"/files/new", "/files/:name", "/files/*path"}, ""},
+		{"same pattern, different methods", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			func() {
+				defer func() {
+					if err := recover(); err != nil {
+						got = fmt.Sprint(err)
+					}
+				}()
+				r := newRouter()
+				r.addRoute("GET", "/hello/:name", nil)
+				r.addRoute("POST", "/hello/:name", nil)
+				for _, pattern := range tt.patterns {
+					r.addRoute("PUT", pattern, nil)
+				}
+			}()
+			if got != tt.panicMsg {
+				t.Fatalf("panic = %q, want %q", got, tt.panicMsg)
+			}
+		})
+	}
+}
+
+func TestMatchPriority(t *testing.T) {
+	patterns := []string{"/hello/b/c", "/hello/:name/c", "/hello/:name", "/hello/*rest", "/hello/b"}
+	tests := []struct {
+		path    string
+		pattern string
+		params  map[string]string
+	}{
+		{"/hello/b", "/hello/b", map[string]string{}},
+		{"/hello/b/c", "/hello/b/c", map[string]string{}},
+		{"/hello/x/c", "/hello/:name/c", map[string]string{"name": "x"}},
+		{"/hello/x", "/hello/:name", map[string]string{"name": "x"}},
+		{"/hello/b/d", "/hello/*rest", map[string]string{"rest": "b/d"}},
+		{"/hello/x/y/z", "/hello/*rest", map[string]string{"rest": "x/y/z"}},
+	}
+	// 无论注册顺序如何，匹配结果都应一致
+	orders := [][]string{patterns, make([]string, len(patterns))}
+	for i, p := range patterns {
+		orders[1][len(patterns)-1-i] = p
+	}
+	for _, order := range orders {
+		r := newRouter()
+		for _, p := range order {
+			r.addRoute("GET", p, nil)
+		}
+		for _, tt := range tests {
+			n, ps := getRoute(r, "GET", tt.path)
+			if n == nil || n.pattern != tt.pattern || !reflect.DeepEqual(ps, tt.params) {
+				t.Errorf("order %v: %s matched %v %v, want %s %v", order, tt.path, n, ps, tt.pattern, tt.params)
+			}
+		}
+	}
+}
+
+func TestRadixCompression(t *testing.T) {
+	r := newRouter()
+	for _, p := range []string{"/hello/bob", "/hello/b/c", "/hello/b", "/help", "/hello/:name/x"} {
+		r.addRoute("GET", p, nil)
+	}
+	tests := map[string]string{
+		"/hello/bob":   "/hello/bob",
+		"/hello/b/c":   "/hello/b/c",
+		"/hello/b":     "/hello/b",
+		"/help":        "/help",
+		"/hello/bo/x":  "/hello/:name/x",
+		"/hello/bob/x": "/hello/:name/x",
+		"/hello/bobby": "",
+		"/hel":         "",
+		"/hello/b/c/d": "",
+	}
+	for path, pattern := range tests {
+		n, _ := getRoute(r, "GET", path)
+		if (n == nil && pattern != "") || (n != nil && n.pattern != pattern) {
+			t.Errorf("%s matched %v, want %q", path, n, pattern)
+		}
+	}
+}
+
+func newBenchmarkRouter() *router {
+	r := newTestRouter()
+	r.addRoute("GET", "/v1/users/:id/books/:book", nil)
+	r.addRoute("GET", "/v1/users/new", nil)
+	return r
+}
+
+func TestGetRouteZeroAllocs(t *testing.T) {
+	r := newBenchmarkRouter()
+	params := make(Params, 0, r.maxParams)
+	for _, path := range []string{"/hello/b/c", "/v1/users/42/books/7", "/assets/css/test.css"} {
+		allocs := testing.AllocsPerRun(100, func() {
+			params = params[:0]
+			if r.getRoute("GET", path, &params) == nil {
+				t.Fatalf("%s should match", path)
+			}
+		})
+		if allocs != 0 {
+			t.Errorf("getRoute(%s) allocates %v times, want 0", path, allocs)
+		}
+	}
+}
+
+func BenchmarkGetRouteStatic(b *testing.B) {
+	r := newBenchmarkRouter()
+	params := make(Params, 0, r.maxParams)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		params = params[:0]
+		r.getRoute("GET", "/v1/users/new", &params)
+	}
+}
+
+func BenchmarkGetRouteParam(b *testing.B) {
+	r := newBenchmarkRouter()
+	params := make(Params, 0, r.maxParams)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		params = params[:0]
+		r.getRoute("GET", "/v1/users/42/books/7", &params)
+	}
+}
@@ -0,0 +1,125 @@
+// Package secure 提供设置安全相关响应头部的中间件，并可以把 HTTP 请求重定向到 HTTPS
+//
+//	r := gee.New()
+//	r.Use(secure.New(secure.Config{
+//		SSLRedirect:     true,
+//		SSLProxyHeaders: map[string]string{"X-Forwarded-Proto": "https"},
+//		STSSeconds:      31536000,
+//		FrameDeny:       true,
+//	}))
+package secure
+
+import (
+	"net/http"
+	"strconv"
+
+	"gee"
+)
+
+// Config 是 secure 中间件的配置，零值的字段不设置对应的头部
+type Config struct {
+	// SSLRedirect 为 true 时，HTTP 请求会被重定向到 HTTPS（GET/HEAD 使用 301，其余方法使用 308 以保留请求体）
+	SSLRedirect bool
+	// SSLHost 是重定向的目标主机，为空时使用请求的 Host
+	SSLHost string
+	// SSLProxyHeaders 用于识别经由反向代理的 HTTPS 请求，例如 {"X-Forwarded-Proto": "https"}
+	// 只有在代理会覆盖这些头部时才能配置，否则客户端可以伪造
+	SSLProxyHeaders map[string]string
+
+	// STSSeconds 是 Strict-Transport-Security 的 max-age，只在 HTTPS 请求中发送
+	STSSeconds           int64
+	STSIncludeSubdomains bool
+	STSPreload           bool
+
+	// FrameDeny 为 true 时设置 X-Frame-Options: DENY；CustomFrameOptions 不为空时使用其值（如 SAMEORIGIN）
+	FrameDeny          bool
+	CustomFrameOptions string
+	// ContentTypeNosniff 为 true 时设置 X-Content-Type-Options: nosniff
+	ContentTypeNosniff bool
+	// ReferrerPolicy 设置 Referrer-Policy，如 strict-origin-when-cross-origin
+	ReferrerPolicy string
+	// ContentSecurityPolicy 设置 Content-Security-Policy，如 default-src 'self'
+	ContentSecurityPolicy string
+}
+
+// DefaultConfig 返回常用的安全配置，不开启 SSLRedirect，HSTS 为一年并包含子域名
+func DefaultConfig() Config {
+	return Config{
+		STSSeconds:            31536000,
+		STSIncludeSubdomains:  true,
+		FrameDeny:             true,
+		ContentTypeNosniff:    true,
+		ReferrerPolicy:        "strict-origin-when-cross-origin",
+		ContentSecurityPolicy: "default-src 'self'",
+	}
+}
+
+// Default 返回使用 DefaultConfig 的中间件
+func Default() gee.HandlerFunc {
+	return New(DefaultConfig())
+}
+
+// New 返回按 config 设置安全头部的中间件
+func New(config Config) gee.HandlerFunc {
+	sts := ""
+	if config.STSSeconds > 0 {
+		sts = "max-age=" + strconv.FormatInt(config.STSSeconds, 10)
+		if config.STSIncludeSubdomains {
+			sts += "; includeSubDomains"
+		}
+		if config.STSPreload {
+			sts += "; preload"
+		}
+	}
+	frameOptions := config.CustomFrameOptions
+	if frameOptions == "" && config.FrameDeny {
+		frameOptions = "DENY"
+	}
+
+	return func(c *gee.Context) {
+		https := isHTTPS(c.Req, config.SSLProxyHeaders)
+		if config.SSLRedirect && !https {
+			host := config.SSLHost
+			if host == "" {
+				host = c.Req.Host
+			}
+			code := http.StatusMovedPermanently
+			if c.Method != http.MethodGet && c.Method != http.MethodHead {
+				code = http.StatusPermanentRedirect
+			}
+			c.Redirect(code, "https://"+host+c.Req.URL.RequestURI())
+			c.Abort()
+			return
+		}
+
+		header := c.Writer.Header()
+		if sts != "" && https {
+			header.Set("Strict-Transport-Security", sts)
+		}
+		if frameOptions != "" {
+			header.Set("X-Frame-Options", frameOptions)
+		}
+		if config.ContentTypeNosniff {
+			header.Set("X-Content-Type-Options", "nosniff")
+		}
+		if config.ReferrerPolicy != "" {
+			header.Set("Referrer-Policy", config.ReferrerPolicy)
+		}
+		if config.ContentSecurityPolicy != "" {
+			header.Set("Content-Security-Policy", config.ContentSecurityPolicy)
+		}
+		c.Next()
+	}
+}
+
+func isHTTPS(req *http.Request, proxyHeaders map[string]string) bool {
+	if req.TLS != nil {
+		return true
+	}
+	for key, value := range proxyHeaders {
+		if req.Header.Get(key) == value {
+			return true
+		}
+	}
+	return false
+}
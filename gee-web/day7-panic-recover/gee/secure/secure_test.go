@@ -0,0 +1,70 @@
+package secure
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gee"
+)
+
+func TestHeaders(t *testing.T) {
+	r := gee.New()
+	r.Use(Default())
+	r.GET("/", func(c *gee.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.TLS = &tls.ConnectionState{}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	want := map[string]string{
+		"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+		"X-Frame-Options":           "DENY",
+		"X-Content-Type-Options":    "nosniff",
+		"Referrer-Policy":           "strict-origin-when-cross-origin",
+		"Content-Security-Policy":   "default-src 'self'",
+	}
+	for key, value := range want {
+		if got := w.Header().Get(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Header().Get("Strict-Transport-Security") != "" {
+		t.Error("HSTS must only be sent over HTTPS")
+	}
+}
+
+func TestSSLRedirect(t *testing.T) {
+	r := gee.New()
+	r.Use(New(Config{SSLRedirect: true, SSLProxyHeaders: map[string]string{"X-Forwarded-Proto": "https"}}))
+	r.Any("/pay", func(c *gee.Context) {
+		c.String(http.StatusOK, "paid")
+	})
+
+	tests := []struct {
+		method, proto string
+		code          int
+		location      string
+	}{
+		{"GET", "", http.StatusMovedPermanently, "https://example.com/pay?id=1"},
+		{"POST", "", http.StatusPermanentRedirect, "https://example.com/pay?id=1"},
+		{"GET", "https", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "http://example.com/pay?id=1", nil)
+		if tt.proto != "" {
+			req.Header.Set("X-Forwarded-Proto", tt.proto)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.code || w.Header().Get("Location") != tt.location {
+			t.Errorf("%s %s: got %d %q", tt.method, tt.proto, w.Code, w.Header().Get("Location"))
+		}
+	}
+}
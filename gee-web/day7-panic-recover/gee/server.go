@@ -0,0 +1,104 @@
+package gee
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+/*
+服务的启动与关闭：Run 系列方法都使用 Engine.Server() 返回的同一个 http.Server，
+因此可以在启动前设置超时等参数，并通过 Shutdown 优雅关闭。
+优雅关闭时先关闭监听，不再接受新连接，再等待处理中的请求全部完成；
+此时 Run 系列方法立即返回 nil，调用方需要等待 Shutdown 返回后再退出进程。
+*/
+
+// Server 返回 Run 系列方法使用的 http.Server，可以在启动前设置 ReadTimeout、WriteTimeout、MaxHeaderBytes 等参数：
+//
+//	srv := r.Server()
+//	srv.ReadTimeout = 5 * time.Second
+//	srv.WriteTimeout = 10 * time.Second
+//
+// Addr 与 Handler 由 Run 系列方法设置
+func (engine *Engine) Server() *http.Server {
+	engine.srvOnce.Do(func() {
+		engine.srv = &http.Server{Handler: engine}
+	})
+	return engine.srv
+}
+
+// Run 在 addr 上监听 HTTP 请求，直到出错或调用 Shutdown
+func (engine *Engine) Run(addr string) (err error) {
+	srv := engine.Server()
+	srv.Addr = addr
+	return serveErr(srv.ListenAndServe())
+}
+
+// RunTLS 在 addr 上监听 HTTPS 请求，certFile、keyFile 为证书与私钥文件
+func (engine *Engine) RunTLS(addr string, certFile string, keyFile string) error {
+	srv := engine.Server()
+	srv.Addr = addr
+	return serveErr(srv.ListenAndServeTLS(certFile, keyFile))
+}
+
+// RunUnix 在 Unix 域套接字 file 上监听 HTTP 请求，file 已存在时会先删除，关闭时删除
+func (engine *Engine) RunUnix(file string) error {
+	if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	listener, err := net.Listen("unix", file)
+	if err != nil {
+		return err
+	}
+	return engine.RunListener(listener)
+}
+
+// RunListener 在已有的 listener 上处理 HTTP 请求，例如由 systemd 传入的监听套接字
+func (engine *Engine) RunListener(listener net.Listener) error {
+	return serveErr(engine.Server().Serve(listener))
+}
+
+// Shutdown 优雅关闭服务：关闭监听后等待处理中的请求完成，ctx 结束时仍未完成则返回 ctx 的错误
+func (engine *Engine) Shutdown(ctx context.Context) error {
+	return engine.Server().Shutdown(ctx)
+}
+
+// ShutdownOnSignal 在收到 signals 中的信号（默认为 SIGINT 和 SIGTERM）后优雅关闭服务，
+// 最多等待 timeout，返回的 channel 在关闭完成后收到 Shutdown 的结果：
+//
+//	done := r.ShutdownOnSignal(10 * time.Second)
+//	if err := r.Run(":9999"); err != nil {
+//		log.Fatal(err)
+//	}
+//	if err := <-done; err != nil {
+//		log.Println("shutdown:", err)
+//	}
+func (engine *Engine) ShutdownOnSignal(timeout time.Duration, signals ...os.Signal) <-chan error {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, signals...)
+	done := make(chan error, 1)
+	go func() {
+		<-quit
+		signal.Stop(quit)
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		done <- engine.Shutdown(ctx)
+	}()
+	return done
+}
+
+// serveErr 把优雅关闭导致的 http.ErrServerClosed 视为正常退出
+func serveErr(err error) error {
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
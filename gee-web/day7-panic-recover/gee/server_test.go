@@ -0,0 +1,208 @@
+package gee
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newServeTestEngine(release <-chan struct{}) *Engine {
+	r := New()
+	r.GET("/ping", func(c *Context) {
+		c.String(http.StatusOK, "pong")
+	})
+	r.GET("/slow", func(c *Context) {
+		<-release
+		c.String(http.StatusOK, "done")
+	})
+	return r
+}
+
+func get(t *testing.T, client *http.Client, url string) string {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+// waitDial 等待 Run 系列方法开始监听
+func waitDial(t *testing.T, network, addr string) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		if conn, err := net.Dial(network, addr); err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("server on %s %s did not start", network, addr)
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	release := make(chan struct{})
+	r := newServeTestEngine(release)
+	r.Server().ReadTimeout = time.Second
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runErr := make(chan error, 1)
+	go func() { runErr <- r.RunListener(l) }()
+	url := "http://" + l.Addr().String()
+
+	slow := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url + "/slow")
+		if err != nil {
+			slow <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		slow <- string(body)
+	}()
+	// 等待 /slow 开始处理后再关闭
+	time.Sleep(50 * time.Millisecond)
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- r.Shutdown(context.Background()) }()
+
+	if err := <-runErr; err != nil {
+		t.Fatalf("Run should return nil after Shutdown, got %v", err)
+	}
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown returned before in-flight request finished: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	if body := <-slow; body != "done" {
+		t.Fatalf("in-flight request got %q", body)
+	}
+	if err := <-shutdown; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := net.Dial("tcp", l.Addr().String()); err == nil {
+		t.Fatal("listener should be closed after Shutdown")
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	r := newServeTestEngine(release)
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	go r.RunListener(l)
+	go http.Get("http://" + l.Addr().String() + "/slow")
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := r.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestShutdownOnSignal(t *testing.T) {
+	r := newServeTestEngine(nil)
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	done := r.ShutdownOnSignal(time.Second, os.Interrupt)
+	runErr := make(chan error, 1)
+	go func() { runErr <- r.RunListener(l) }()
+	waitDial(t, "tcp", l.Addr().String())
+
+	p, _ := os.FindProcess(os.Getpid())
+	if err := p.Signal(os.Interrupt); err != nil {
+		t.Skip("sending signals is not supported:", err)
+	}
+	if err := <-runErr; err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunUnix(t *testing.T) {
+	r := newServeTestEngine(nil)
+	sock := filepath.Join(t.TempDir(), "gee.sock")
+	go r.RunUnix(sock)
+	defer r.Shutdown(context.Background())
+	waitDial(t, "unix", sock)
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", sock)
+		},
+	}}
+	if body := get(t, client, "http://unix/ping"); body != "pong" {
+		t.Fatalf("got %q", body)
+	}
+}
+
+// writeTestCert 生成自签名证书，返回证书与私钥文件的路径
+func writeTestCert(t *testing.T) (certFile, keyFile string, pool *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "gee test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	cert, _ := x509.ParseCertificate(der)
+	pool = x509.NewCertPool()
+	pool.AddCert(cert)
+	return
+}
+
+func TestRunTLS(t *testing.T) {
+	certFile, keyFile, pool := writeTestCert(t)
+	// RunTLS 不返回实际监听的地址，先占用一个空闲端口再释放
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := l.Addr().String()
+	l.Close()
+
+	r := newServeTestEngine(nil)
+	go r.RunTLS(addr, certFile, keyFile)
+	defer r.Shutdown(context.Background())
+	waitDial(t, "tcp", addr)
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	if body := get(t, client, "https://"+addr+"/ping"); body != "pong" {
+		t.Fatalf("got %q", body)
+	}
+}
@@ -0,0 +1,245 @@
+package gee
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+静态文件服务：文件来自 fs.FS，因此既可以是磁盘上的目录（os.DirFS），也可以是编译进二进制的 embed.FS
+  - 每个文件都带有根据内容计算的强 ETag，客户端携带 If-None-Match 时返回 304
+  - Cache-Control 按文件路径前缀配置，例如带哈希的 assets/ 长期缓存，index.html 每次验证
+  - 开启 Precompressed 后，客户端支持时优先返回预先压缩好的同名 .br、.gz 文件
+  - 开启 SPA 后，不存在的路径（不带扩展名）返回根目录的 index.html，由前端路由处理
+*/
+
+// StaticConfig 是 StaticFSWithConfig 的配置
+type StaticConfig struct {
+	// DisableListing 为 true 时，没有 index.html 的目录返回 404 而不是文件列表
+	DisableListing bool
+	// CacheControl 为文件路径前缀（相对于 fs 的根目录，不以 "/" 开头）到 Cache-Control 值的映射，
+	// 最长的前缀优先，"" 匹配所有文件，例如 {"": "no-cache", "assets/": "public, max-age=31536000, immutable"}
+	CacheControl map[string]string
+	// Precompressed 为 true 时，根据 Accept-Encoding 返回同名的 .br 或 .gz 文件
+	Precompressed bool
+	// SPA 为 true 时，不存在且不带扩展名的路径返回根目录的 index.html
+	SPA bool
+}
+
+// 预压缩文件的后缀与对应的 Content-Encoding，按优先级排列
+var precompressedEncodings = []struct {
+	encoding, ext string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// StaticFS 在 relativePath 下提供 fsys 中的文件，使用默认配置（允许目录列表，不设置 Cache-Control）
+//
+//	//go:embed assets
+//	var assets embed.FS
+//
+//	sub, _ := fs.Sub(assets, "assets")
+//	r.StaticFS("/assets", sub)
+func (group *RouterGroup) StaticFS(relativePath string, fsys fs.FS) {
+	group.StaticFSWithConfig(relativePath, fsys, StaticConfig{})
+}
+
+// StaticFSWithConfig 与 StaticFS 相同，但可以指定缓存、预压缩与 SPA 等配置
+func (group *RouterGroup) StaticFSWithConfig(relativePath string, fsys fs.FS, config StaticConfig) {
+	s := &staticServer{
+		fsys:       fsys,
+		config:     config,
+		fileServer: http.StripPrefix(path.Join(group.prefix, relativePath), http.FileServer(http.FS(fsys))),
+	}
+	group.GET(path.Join(relativePath, "/*filepath"), s.serve)
+	// *filepath 不匹配空路径，根路径单独注册
+	group.GET(relativePath, s.serve)
+}
+
+type staticServer struct {
+	fsys       fs.FS
+	config     StaticConfig
+	fileServer http.Handler // 用于没有 index.html 的目录列表
+	etags      sync.Map     // etagKey -> ETag，文件内容不变时只计算一次
+}
+
+type etagKey struct {
+	name    string
+	modTime time.Time
+	size    int64
+}
+
+func (s *staticServer) serve(c *Context) {
+	name := strings.TrimPrefix(path.Clean("/"+c.Param("filepath")), "/")
+	if name == "" {
+		name = "."
+	}
+	info, err := fs.Stat(s.fsys, name)
+	if err != nil {
+		if s.config.SPA && path.Ext(name) == "" && s.serveFile(c, "index.html") {
+			return
+		}
+		c.Status(http.StatusNotFound)
+		return
+	}
+	if !info.IsDir() {
+		s.serveFile(c, name)
+		return
+	}
+	index := path.Join(name, "index.html")
+	indexInfo, err := fs.Stat(s.fsys, index)
+	hasIndex := err == nil && indexInfo.Mode().IsRegular()
+	if !hasIndex && s.config.DisableListing {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	// 目录的 URL 以 "/" 结尾，index.html 和文件列表中的相对链接才能正确解析，
+	// 包括注册在 relativePath 上、不带 "/" 的根路径
+	if !strings.HasSuffix(c.Req.URL.Path, "/") {
+		location := c.Req.URL.Path + "/"
+		if c.Req.URL.RawQuery != "" {
+			location += "?" + c.Req.URL.RawQuery
+		}
+		c.Redirect(http.StatusMovedPermanently, location)
+		return
+	}
+	if hasIndex {
+		s.serveFile(c, index)
+		return
+	}
+	if cc := s.cacheControl(name); cc != "" {
+		c.SetHeader("Cache-Control", cc)
+	}
+	s.fileServer.ServeHTTP(c.Writer, c.Req)
+}
+
+// serveFile 返回普通文件 name 的内容，name 不存在或不是普通文件时返回 false
+func (s *staticServer) serveFile(c *Context, name string) bool {
+	info, err := fs.Stat(s.fsys, name)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	served, encoding := name, ""
+	if s.config.Precompressed {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		if ctype := mime.TypeByExtension(path.Ext(name)); ctype != "" {
+			served, encoding = s.precompressed(c.Req, name)
+			if encoding != "" {
+				c.SetHeader("Content-Type", ctype)
+				c.SetHeader("Content-Encoding", encoding)
+			}
+		}
+	}
+
+	f, err := s.fsys.Open(served)
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return true
+	}
+	defer f.Close()
+	info, err = f.Stat()
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return true
+	}
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return true
+		}
+		content = bytes.NewReader(data)
+	}
+
+	etag, err := s.etag(served, info, content)
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return true
+	}
+	c.SetHeader("ETag", etag)
+	if cc := s.cacheControl(name); cc != "" {
+		c.SetHeader("Cache-Control", cc)
+	}
+	// 使用原文件名，Content-Type 按原文件的扩展名确定
+	http.ServeContent(c.Writer, c.Req, name, info.ModTime(), content)
+	return true
+}
+
+// precompressed 返回客户端可以接受的预压缩文件及其编码，没有时返回 name 本身
+func (s *staticServer) precompressed(req *http.Request, name string) (string, string) {
+	accept := req.Header.Get("Accept-Encoding")
+	for _, pc := range precompressedEncodings {
+		if !acceptsEncoding(accept, pc.encoding) {
+			continue
+		}
+		if info, err := fs.Stat(s.fsys, name+pc.ext); err == nil && info.Mode().IsRegular() {
+			return name + pc.ext, pc.encoding
+		}
+	}
+	return name, ""
+}
+
+// acceptsEncoding 判断 Accept-Encoding 是否接受 encoding，明确列出的编码优先于 *，q=0 表示拒绝
+func acceptsEncoding(accept, encoding string) bool {
+	wildcard := false
+	for _, part := range strings.Split(accept, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.TrimSpace(coding)
+		if !strings.EqualFold(coding, encoding) && coding != "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if coding != "*" {
+			return q > 0
+		}
+		wildcard = q > 0
+	}
+	return wildcard
+}
+
+// etag 返回文件内容的强 ETag，计算后把 content 的读取位置恢复到开头
+func (s *staticServer) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	key := etagKey{name: name, modTime: info.ModTime(), size: info.Size()}
+	if etag, ok := s.etags.Load(key); ok {
+		return etag.(string), nil
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := fmt.Sprintf(`"%s"`, hex.EncodeToString(h.Sum(nil)[:16]))
+	s.etags.Store(key, etag)
+	return etag, nil
+}
+
+// cacheControl 返回与 name 匹配的最长前缀对应的 Cache-Control
+func (s *staticServer) cacheControl(name string) string {
+	best, value := -1, ""
+	for prefix, cc := range s.config.CacheControl {
+		if strings.HasPrefix(name, prefix) && len(prefix) > best {
+			best, value = len(prefix), cc
+		}
+	}
+	return value
+}
@@ -0,0 +1,103 @@
+package gee
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"gee/internal/geetest"
+)
+
+var testStaticFS = fstest.MapFS{
+	"index.html":       {Data: []byte("<h1>index</h1>")},
+	"app.js":           {Data: []byte("console.log('app')")},
+	"app.js.br":        {Data: []byte("br-bytes")},
+	"app.js.gz":        {Data: []byte("gz-bytes")},
+	"assets/logo.txt":  {Data: []byte("logo")},
+	"docs/readme.txt":  {Data: []byte("readme")},
+	"empty/.gitignore": {Data: []byte("")},
+}
+
+func TestStaticFS(t *testing.T) {
+	r := New()
+	r.StaticFS("/static", testStaticFS)
+
+	w := geetest.PerformRequest(r, "GET", "/static/assets/logo.txt")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || w.Body.String() != "logo" || !strings.HasPrefix(etag, `"`) {
+		t.Fatalf("unexpected response %d %q etag=%q", w.Code, w.Body.String(), etag)
+	}
+	if w := geetest.PerformRequest(r, "GET", "/static/assets/logo.txt", "If-None-Match", etag); w.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 for matching ETag, got %d", w.Code)
+	}
+	if w := geetest.PerformRequest(r, "GET", "/static/missing.txt"); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+	if w := geetest.PerformRequest(r, "GET", "/static/docs/"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "readme.txt") {
+		t.Fatalf("expected directory listing, got %d %q", w.Code, w.Body.String())
+	}
+	for _, p := range []string{"/static", "/static/docs"} {
+		if w := geetest.PerformRequest(r, "GET", p+"?v=1"); w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != p+"/?v=1" {
+			t.Fatalf("%s: expected redirect to the trailing-slash URL, got %d %q", p, w.Code, w.Header().Get("Location"))
+		}
+	}
+	if w := geetest.PerformRequest(r, "GET", "/static/app.js", "Accept-Encoding", "gzip, br"); w.Body.String() != "console.log('app')" || w.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("precompressed files are disabled by default, got %q", w.Body.String())
+	}
+}
+
+func TestStaticFSIndexCacheControl(t *testing.T) {
+	r := New()
+	r.StaticFSWithConfig("/static", testStaticFS, StaticConfig{CacheControl: map[string]string{"": "no-cache"}})
+
+	w := geetest.PerformRequest(r, "GET", "/static/")
+	if w.Code != http.StatusOK || w.Body.String() != "<h1>index</h1>" || w.Header().Get("ETag") == "" ||
+		w.Header().Get("Cache-Control") != "no-cache" {
+		t.Fatalf("index.html should be served with the cache policy when listing is enabled, got %d %q %v",
+			w.Code, w.Body.String(), w.Header())
+	}
+	w = geetest.PerformRequest(r, "GET", "/static/docs/")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "readme.txt") || w.Header().Get("Cache-Control") != "no-cache" {
+		t.Fatalf("directory listing should use the cache policy, got %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+}
+
+func TestStaticFSWithConfig(t *testing.T) {
+	r := New()
+	r.GET("/api/ping", func(c *Context) { c.String(http.StatusOK, "pong") })
+	r.StaticFSWithConfig("/", testStaticFS, StaticConfig{
+		DisableListing: true,
+		Precompressed:  true,
+		SPA:            true,
+		CacheControl:   map[string]string{"": "no-cache", "assets/": "public, max-age=31536000, immutable"},
+	})
+
+	tests := []struct {
+		path, acceptEncoding      string
+		code                      int
+		body, encoding, cacheCtrl string
+	}{
+		{"/", "", http.StatusOK, "<h1>index</h1>", "", "no-cache"},
+		{"/users/42", "", http.StatusOK, "<h1>index</h1>", "", "no-cache"},
+		{"/assets/logo.txt", "", http.StatusOK, "logo", "", "public, max-age=31536000, immutable"},
+		{"/assets/missing.png", "", http.StatusNotFound, "", "", ""},
+		{"/docs", "", http.StatusNotFound, "", "", ""},
+		{"/app.js", "gzip, br", http.StatusOK, "br-bytes", "br", "no-cache"},
+		{"/app.js", "gzip, br;q=0", http.StatusOK, "gz-bytes", "gzip", "no-cache"},
+		{"/app.js", "identity", http.StatusOK, "console.log('app')", "", "no-cache"},
+		{"/api/ping", "", http.StatusOK, "pong", "", ""},
+	}
+	for _, tt := range tests {
+		w := geetest.PerformRequest(r, "GET", tt.path, "Accept-Encoding", tt.acceptEncoding)
+		if w.Code != tt.code || w.Body.String() != tt.body || w.Header().Get("Content-Encoding") != tt.encoding ||
+			w.Header().Get("Cache-Control") != tt.cacheCtrl {
+			t.Errorf("%s (%s): got %d %q encoding=%q cache=%q", tt.path, tt.acceptEncoding, w.Code, w.Body.String(),
+				w.Header().Get("Content-Encoding"), w.Header().Get("Cache-Control"))
+		}
+	}
+	if w := geetest.PerformRequest(r, "GET", "/app.js", "Accept-Encoding", "br"); !strings.Contains(w.Header().Get("Content-Type"), "javascript") ||
+		w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("unexpected headers for precompressed file %v", w.Header())
+	}
+}
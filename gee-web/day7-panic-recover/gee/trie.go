@@ -1,87 +1,225 @@
 package gee
 
 import (
-    "fmt"
-    "strings"
+	"fmt"
+	"strings"
 )
 
+/*
+基数树（压缩前缀树）路由
+与按 "/" 切分的 Trie 树不同，基数树的静态节点存储的是若干路由共同的前缀字符串，
+只有一个子节点的链会被压缩成一个节点。例如注册 /hello/b/c 与 /hello/bob 后：
+
+	/hello/b
+	├── /c
+	└── ob
+
+通配符单独作为子节点：每个节点最多有一个 :param 子节点和一个 *catchall 子节点，
+匹配时按 静态 > :param > *catchall 的优先级查找，失败时回溯。
+处理函数直接存储在节点上，查找过程中把参数写入调用方提供的 Params，不产生内存分配。
+*/
+
+// Param 是路由中的一个参数，Key 为参数名，Value 为请求路径中对应的值
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Params 按在路由中出现的顺序保存参数
+type Params []Param
+
+// Get 返回第一个名为 name 的参数的值，以及是否存在该参数
+func (ps Params) Get(name string) (string, bool) {
+	for _, p := range ps {
+		if p.Key == name {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// ByName 返回第一个名为 name 的参数的值，不存在时返回空字符串
+func (ps Params) ByName(name string) string {
+	value, _ := ps.Get(name)
+	return value
+}
+
 type node struct {
-    pattern string  //存储路径，如果不为空，说明当前节点是一个叶子节点
-    part string  //存储的是路径的一段
-    children []*node //子节点切片
-    isWild bool  //是否是通配符
+	path       string        // 静态节点为压缩后的路径片段；通配符节点为 ":name" 或 "*name"
+	indices    string        // 静态子节点 path 的首字节，与 children 一一对应
+	children   []*node       // 静态子节点
+	paramChild *node         // :param 子节点
+	catchAll   *node         // *catchall 子节点
+	pattern    string        // 注册的完整路由，不为空说明该节点上注册了路由
+	handlers   []HandlerFunc // 注册时计算好的处理链：分组中间件 + 路由中间件 + 处理函数
 }
 
 func (n *node) String() string {
-    return fmt.Sprintf("node{pattern=%s, part=%s, isWild=%t}", n.pattern, n.part, n.isWild)
+	return fmt.Sprintf("node{pattern=%s, path=%s}", n.pattern, n.path)
 }
 
-//插入路径
-//参数 pattern 是待插入的路径模式，参数 parts 是路径模式的各个部分切片，参数 height 表示当前插入的部分在切片中的位置
-func (n *node) insert(pattern string, parts []string, height int) {
-    if len(parts) == height {
-        n.pattern = pattern
-        return
-    }
-
-    part := parts[height]
-    child := n.matchChild(part)
-    if child == nil {
-        child = &node{part: part, isWild: part[0] == ':' || part[0] == '*'}
-        n.children = append(n.children, child)
-    }
-    child.insert(pattern, parts, height + 1)
+// token 是路由模式解析后的一段：静态字符串，或一个通配符
+type token struct {
+	static string // 静态片段，包含其中的 "/"
+	wild   string // 通配符，如 ":name"、"*filepath"
 }
 
-func (n *node) search(parts []string, height int) *node {
-    if len(parts) == height || strings.HasPrefix(n.part, "*") {
-        if n.pattern == "" {
-            return nil
-        }
-        return n
-    }
-
-    part := parts[height]
-    //获取所有与当前部分匹配的子节点
-    children := n.matchChildren(part)
-
-    for _, child := range children {
-        result := child.search(parts, height + 1)
-        if result != nil {
-            return result
-        }
-    }
-
-    return nil
+// tokenize 把已清理的路由模式切分为静态片段与通配符交替的序列
+// 例如 /hello/:name/c 得到 "/hello/"、":name"、"/c"
+func tokenize(pattern string) []token {
+	tokens := make([]token, 0)
+	start := 0
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != ':' && pattern[i] != '*' || i == 0 || pattern[i-1] != '/' {
+			continue
+		}
+		if start < i {
+			tokens = append(tokens, token{static: pattern[start:i]})
+		}
+		end := strings.IndexByte(pattern[i:], '/')
+		if end < 0 {
+			end = len(pattern) - i
+		}
+		tokens = append(tokens, token{wild: pattern[i : i+end]})
+		start = i + end
+		i = start - 1
+	}
+	if start < len(pattern) {
+		tokens = append(tokens, token{static: pattern[start:]})
+	}
+	return tokens
 }
 
-//遍历路由树中的节点，并将 具有模式的节点 添加到给定的切片列表中
-func (n *node) travel(list *([]*node)) {
-    if n.pattern != "" {
-        *list = append(*list, n)
-    }
-    for _, child := range n.children {
-        child.travel(list)
-    }
+// insert 注册一条路由，返回该路由中命名参数的个数
+// 同一位置上名字不同的两个通配符（如 /hello/:name 与 /hello/:id）、重复的路由都会在注册时 panic
+func (n *node) insert(pattern string, cleaned string, handlers []HandlerFunc) int {
+	numParams := 0
+	prefix := ""
+	for _, t := range tokenize(cleaned) {
+		if t.static != "" {
+			n = n.insertStatic(t.static)
+			prefix += t.static
+			continue
+		}
+		child := &node{path: t.wild}
+		existing := &n.paramChild
+		if t.wild[0] == '*' {
+			existing = &n.catchAll
+		}
+		if *existing == nil {
+			*existing = child
+		} else if (*existing).path != t.wild {
+			panic(fmt.Sprintf("gee: wildcard '%s' in pattern '%s' conflicts with existing wildcard '%s' in '%s'",
+				t.wild, pattern, (*existing).path, prefix+(*existing).path))
+		}
+		n = *existing
+		prefix += t.wild
+		if len(t.wild) > 1 {
+			numParams++
+		}
+	}
+	if n.pattern != "" {
+		panic(fmt.Sprintf("gee: pattern '%s' conflicts with existing pattern '%s'", pattern, n.pattern))
+	}
+	n.pattern = pattern
+	n.handlers = handlers
+	return numParams
 }
 
-//查找首个匹配的子节点
-func (n *node) matchChild(part string) *node {
-    for _, child := range n.children {
-        if child.part == part || child.isWild {
-            return child
-        }
-    }
-    return nil
+// insertStatic 沿静态子节点插入片段 s，必要时拆分已有节点，返回 s 结束处的节点
+func (n *node) insertStatic(s string) *node {
+	for len(s) > 0 {
+		i := strings.IndexByte(n.indices, s[0])
+		if i < 0 {
+			child := &node{path: s}
+			n.indices += s[:1]
+			n.children = append(n.children, child)
+			return child
+		}
+		child := n.children[i]
+		l := longestCommonPrefix(s, child.path)
+		if l < len(child.path) {
+			child.split(l)
+		}
+		s = s[l:]
+		n = child
+	}
+	return n
 }
 
-//查找所有匹配的子节点
-func (n *node) matchChildren(part string) []*node {
-    nodes := make([]*node, 0)
-    for _, child := range n.children {
-        if child.part == part || child.isWild {
-            nodes = append(nodes, child)
-        }
-    }
-    return nodes
-}
\ No newline at end of file
+// split 在第 i 个字节处把节点拆分为父子两个节点，原有的子节点与路由都移到新的子节点上
+func (n *node) split(i int) {
+	child := *n
+	child.path = n.path[i:]
+	*n = node{
+		path:     n.path[:i],
+		indices:  child.path[:1],
+		children: []*node{&child},
+	}
+}
+
+func longestCommonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// search 匹配节点自身 path 之后剩余的请求路径 path，匹配到的参数追加到 params 中
+func (n *node) search(path string, params *Params) *node {
+	if path == "" {
+		if n.pattern == "" {
+			return nil
+		}
+		return n
+	}
+
+	if i := strings.IndexByte(n.indices, path[0]); i >= 0 {
+		child := n.children[i]
+		if strings.HasPrefix(path, child.path) {
+			if result := child.search(path[len(child.path):], params); result != nil {
+				return result
+			}
+		}
+	}
+
+	if child := n.paramChild; child != nil {
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
+		}
+		if end > 0 {
+			*params = append(*params, Param{Key: child.path[1:], Value: path[:end]})
+			if result := child.search(path[end:], params); result != nil {
+				return result
+			}
+			*params = (*params)[:len(*params)-1]
+		}
+	}
+
+	// *catchall 匹配剩余的全部路径，参数值中不包含开头的 "/"
+	if child := n.catchAll; child != nil {
+		if len(child.path) > 1 {
+			*params = append(*params, Param{Key: child.path[1:], Value: path})
+		}
+		return child
+	}
+	return nil
+}
+
+// 遍历路由树中的节点，并将 具有模式的节点 添加到给定的切片列表中
+func (n *node) travel(list *([]*node)) {
+	if n.pattern != "" {
+		*list = append(*list, n)
+	}
+	for _, child := range n.children {
+		child.travel(list)
+	}
+	if n.paramChild != nil {
+		n.paramChild.travel(list)
+	}
+	if n.catchAll != nil {
+		n.catchAll.travel(list)
+	}
+}
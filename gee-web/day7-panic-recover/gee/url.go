@@ -0,0 +1,90 @@
+package gee
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"strings"
+)
+
+// Route 是一条已注册的路由，由 GET、POST 等方法返回
+type Route struct {
+	engine  *Engine
+	Method  string
+	Pattern string // 注册时的完整路由模式，包含分组前缀
+}
+
+// Name 为路由命名，之后可以通过 Engine.URL 根据名字生成 URL
+// 同一个名字只能使用一次，重复命名会 panic
+func (r *Route) Name(name string) *Route {
+	if existing, ok := r.engine.namedRoutes[name]; ok {
+		panic(fmt.Sprintf("gee: route name '%s' is already used by pattern '%s'", name, existing))
+	}
+	r.engine.namedRoutes[name] = r.Pattern
+	return r
+}
+
+// URL 根据路由名生成 URL，params 按在路由模式中出现的顺序依次填入 :param 与 *catchall
+// 例如路由 /v1/users/:id/*filepath 命名为 user.file 时，URL("user.file", 42, "a/b.txt") 返回 /v1/users/42/a/b.txt
+// :param 的值会被转义，但不能包含 "/"：路由按解码后的路径匹配，%2F 会被当作路径分隔符，生成的 URL 无法匹配回该路由；
+// 只有 *catchall 的值可以包含 "/"。参数个数与路由中的通配符个数不一致时返回错误
+func (engine *Engine) URL(name string, params ...interface{}) (string, error) {
+	pattern, ok := engine.namedRoutes[name]
+	if !ok {
+		return "", fmt.Errorf("gee: no route named '%s'", name)
+	}
+	parts := parsePattern(pattern)
+	wildcards := 0
+	for _, part := range parts {
+		if part[0] == ':' || part[0] == '*' {
+			wildcards++
+		}
+	}
+	if len(params) != wildcards {
+		return "", fmt.Errorf("gee: route '%s' (%s) requires %d params, got %d", name, pattern, wildcards, len(params))
+	}
+
+	var buf strings.Builder
+	i := 0
+	for _, part := range parts {
+		buf.WriteByte('/')
+		switch part[0] {
+		case ':':
+			value := fmt.Sprint(params[i])
+			if value == "" {
+				return "", fmt.Errorf("gee: param '%s' of route '%s' must not be empty", part, name)
+			}
+			if strings.Contains(value, "/") {
+				return "", fmt.Errorf("gee: param '%s' of route '%s' must not contain '/', got %q", part, name, value)
+			}
+			buf.WriteString(url.PathEscape(value))
+			i++
+		case '*':
+			value := strings.TrimPrefix(fmt.Sprint(params[i]), "/")
+			if value == "" {
+				return "", fmt.Errorf("gee: param '%s' of route '%s' must not be empty", part, name)
+			}
+			segments := strings.Split(value, "/")
+			for j, seg := range segments {
+				segments[j] = url.PathEscape(seg)
+			}
+			buf.WriteString(strings.Join(segments, "/"))
+			i++
+		default:
+			buf.WriteString(part)
+		}
+	}
+	if buf.Len() == 0 {
+		return "/", nil
+	}
+	return buf.String(), nil
+}
+
+// templateFuncs 返回加载模板时使用的函数：默认的 url 函数，以及通过 SetFuncMap 设置的函数（同名时覆盖默认函数）
+func (engine *Engine) templateFuncs() template.FuncMap {
+	funcs := template.FuncMap{"url": engine.URL}
+	for name, fn := range engine.funcMap {
+		funcs[name] = fn
+	}
+	return funcs
+}
@@ -0,0 +1,98 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestURL(t *testing.T) {
+	r := New()
+	var got []string
+	called := false
+	record := func(c *Context) {
+		called, got = true, []string{}
+		for _, p := range c.Params {
+			got = append(got, p.Value)
+		}
+	}
+	v1 := r.Group("/v1")
+	v1.GET("/hello/:name", record).Name("hello")
+	v1.GET("/users/:id/files/*filepath", record).Name("user.file")
+	r.GET("/", record).Name("index")
+
+	tests := []struct {
+		name   string
+		params []interface{}
+		want   string
+		routed []string // 按生成的 URL 请求时，路由解析出的参数
+	}{
+		{"hello", []interface{}{"geektutu"}, "/v1/hello/geektutu", []string{"geektutu"}},
+		{"hello", []interface{}{"a b?c"}, "/v1/hello/a%20b%3Fc", []string{"a b?c"}},
+		{"user.file", []interface{}{42, "/css/a b.css"}, "/v1/users/42/files/css/a%20b.css", []string{"42", "css/a b.css"}},
+		{"index", nil, "/", []string{}},
+	}
+	for _, tt := range tests {
+		u, err := r.URL(tt.name, tt.params...)
+		if err != nil || u != tt.want {
+			t.Errorf("URL(%s, %v) = %q, %v, want %q", tt.name, tt.params, u, err, tt.want)
+			continue
+		}
+		// 生成的 URL 必须能路由回同一条路由，并还原出相同的参数
+		called = false
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", u, nil))
+		if w.Code != http.StatusOK || !called || strings.Join(got, "|") != strings.Join(tt.routed, "|") {
+			t.Errorf("GET %s: got %d params %q, want %q", u, w.Code, got, tt.routed)
+		}
+	}
+
+	if _, err := r.URL("hello", "a/b"); err == nil {
+		t.Error("':param' value containing '/' should fail")
+	}
+	for _, bad := range [][]interface{}{{}, {"a", "b"}, {""}} {
+		if _, err := r.URL("hello", bad...); err == nil {
+			t.Errorf("URL(hello, %v) should fail", bad)
+		}
+	}
+	if _, err := r.URL("missing"); err == nil {
+		t.Error("unknown route name should fail")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("duplicate route name should panic")
+		}
+	}()
+	r.POST("/other", func(c *Context) {}).Name("hello")
+}
+
+func TestURLTemplateFunc(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "link.tmpl"), []byte(`<a href="{{url "hello" .}}">{{upper .}}</a>`), 0644)
+	os.WriteFile(filepath.Join(dir, "broken.tmpl"), []byte(`{{url "hello"}}`), 0644)
+
+	r := New()
+	r.SetFuncMap(map[string]interface{}{"upper": strings.ToUpper})
+	r.LoadHTMLGlob(filepath.Join(dir, "*.tmpl"))
+	r.GET("/hello/:name", func(c *Context) {
+		c.HTML(http.StatusOK, "link.tmpl", c.Param("name"))
+	}).Name("hello")
+	r.GET("/broken", func(c *Context) {
+		c.HTML(http.StatusOK, "broken.tmpl", nil)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/hello/gee", nil))
+	if w.Body.String() != `<a href="/hello/gee">GEE</a>` {
+		t.Fatalf("unexpected body %q", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/broken", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("missing params should fail rendering, got %d", w.Code)
+	}
+}
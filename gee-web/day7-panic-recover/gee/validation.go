@@ -0,0 +1,314 @@
+package gee
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/*
+声明式校验：通过 binding 标签为字段声明规则，多个规则用逗号分隔，例如
+
+	type Login struct {
+		User     string `json:"user" binding:"required,min=3,max=16"`
+		Role     string `json:"role" binding:"oneof=admin guest"`
+		Email    string `json:"email" binding:"required,email"`
+		Code     string `json:"code" binding:"regexp=^[0-9]{6}$"`
+	}
+
+支持的规则：
+  - required  不能为零值（切片、map 不能为空）
+  - min=n / max=n  数字比较值的大小，字符串、切片、map 比较长度
+  - gte=n / lte=n  数字必须大于等于、小于等于 n
+  - len=n  字符串、切片、map 的长度必须为 n
+  - oneof=a b c  值必须是空格分隔的候选值之一
+  - email  必须是合法的邮箱地址
+  - regexp=expr  必须匹配正则表达式，由于表达式中可能包含逗号，该规则必须放在最后
+
+未设置的可选字段不做 required 以外的校验：nil 指针，以及空字符串、空切片和空 map。
+数字、布尔值等其他类型的零值仍然按规则校验，例如 min=18 会拒绝 0。结构体字段（及其指针）会递归校验
+*/
+
+// FieldError 描述一个字段未通过的校验规则
+type FieldError struct {
+	Field string      `json:"field"`           // 字段路径，如 Address.City
+	Tag   string      `json:"tag"`             // 未通过的规则名
+	Param string      `json:"param,omitempty"` // 规则参数
+	Value interface{} `json:"value"`           // 字段的实际值
+}
+
+func (fe FieldError) Error() string {
+	if fe.Param == "" {
+		return fmt.Sprintf("field '%s' failed on the '%s' rule", fe.Field, fe.Tag)
+	}
+	return fmt.Sprintf("field '%s' failed on the '%s=%s' rule", fe.Field, fe.Tag, fe.Param)
+}
+
+// ValidationErrors 是校验失败的全部字段，可以直接作为 JSON 返回给客户端
+type ValidationErrors []FieldError
+
+func (ve ValidationErrors) Error() string {
+	msgs := make([]string, len(ve))
+	for i, fe := range ve {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+var emailRegexp = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
+
+// 编译过的 regexp 规则，避免每次校验都重新编译
+var regexpCache sync.Map
+
+// InvalidRuleError 表示 binding 标签本身有误：未知的规则、参数不合法或规则不适用于字段的类型
+// 这是程序的错误而不是请求的错误，Bind 遇到它时返回 500
+type InvalidRuleError struct {
+	Field string // 字段路径
+	Rule  string // 出错的规则，如 min=abc
+	Err   error
+}
+
+func (e *InvalidRuleError) Error() string {
+	return fmt.Sprintf("gee: invalid binding rule '%s' on field %s: %v", e.Rule, e.Field, e.Err)
+}
+
+func (e *InvalidRuleError) Unwrap() error {
+	return e.Err
+}
+
+// Validate 按 binding 标签校验 obj，obj 为结构体或结构体指针，全部通过时返回 nil，否则返回 ValidationErrors
+// 标签本身有误时返回 *InvalidRuleError
+func Validate(obj interface{}) error {
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	var errs ValidationErrors
+	if err := validateStruct(v, "", &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateStruct(v reflect.Value, prefix string, errs *ValidationErrors) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		name := prefix + field.Name
+		if tag := field.Tag.Get("binding"); tag != "" && tag != "-" {
+			if err := validateField(fv, name, tag, errs); err != nil {
+				return err
+			}
+		}
+		// 递归校验嵌套的结构体
+		inner := fv
+		if inner.Kind() == reflect.Ptr && !inner.IsNil() {
+			inner = inner.Elem()
+		}
+		if inner.Kind() == reflect.Struct {
+			if err := validateStruct(inner, name+".", errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateField(fv reflect.Value, name string, tag string, errs *ValidationErrors) error {
+	for tag != "" {
+		var rule string
+		if strings.HasPrefix(tag, "regexp=") {
+			rule, tag = tag, ""
+		} else if i := strings.IndexByte(tag, ','); i >= 0 {
+			rule, tag = tag[:i], tag[i+1:]
+		} else {
+			rule, tag = tag, ""
+		}
+		key, param, _ := strings.Cut(rule, "=")
+		// 先检查规则本身，字段未设置时同样能发现标签的错误
+		if err := checkRuleSyntax(key, param); err != nil {
+			return &InvalidRuleError{Field: name, Rule: rule, Err: err}
+		}
+		if key != "required" && isUnset(fv) {
+			continue
+		}
+		ok, err := checkRule(fv, key, param)
+		if err != nil {
+			return &InvalidRuleError{Field: name, Rule: rule, Err: err}
+		}
+		if !ok {
+			*errs = append(*errs, FieldError{Field: name, Tag: key, Param: param, Value: fieldValue(fv)})
+		}
+	}
+	return nil
+}
+
+// isUnset 判断可选字段是否未设置：nil 指针、interface，以及长度为 0 的字符串、切片和 map
+// 数字的 0 是有意义的值，不算未设置
+func isUnset(fv reflect.Value) bool {
+	switch fv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return fv.IsNil()
+	case reflect.String, reflect.Slice, reflect.Map:
+		return fv.Len() == 0
+	}
+	return false
+}
+
+func fieldValue(fv reflect.Value) interface{} {
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			return nil
+		}
+		fv = fv.Elem()
+	}
+	return fv.Interface()
+}
+
+// checkRuleSyntax 检查规则名是否已知、参数是否合法，与字段的值无关
+func checkRuleSyntax(key, param string) error {
+	switch key {
+	case "required", "email":
+		return nil
+	case "gte", "lte", "min", "max", "len":
+		_, err := strconv.ParseFloat(param, 64)
+		return err
+	case "oneof":
+		if strings.TrimSpace(param) == "" {
+			return fmt.Errorf("oneof rule requires at least one candidate")
+		}
+		return nil
+	case "regexp":
+		_, err := compileRegexp(param)
+		return err
+	default:
+		return fmt.Errorf("unknown rule %s", key)
+	}
+}
+
+// compileRegexp 返回编译后的正则表达式，结果被缓存
+func compileRegexp(expr string) (*regexp.Regexp, error) {
+	if re, ok := regexpCache.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	compiled, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	re, _ := regexpCache.LoadOrStore(expr, compiled)
+	return re.(*regexp.Regexp), nil
+}
+
+// checkRule 检查 fv 是否满足规则，规则本身不合法时返回 error
+func checkRule(fv reflect.Value, key, param string) (bool, error) {
+	if key == "required" {
+		switch fv.Kind() {
+		case reflect.Slice, reflect.Map:
+			return fv.Len() > 0, nil
+		default:
+			return !fv.IsZero(), nil
+		}
+	}
+	for fv.Kind() == reflect.Ptr {
+		fv = fv.Elem()
+	}
+	switch key {
+	case "gte", "lte":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return false, err
+		}
+		n, err := number(fv)
+		if err != nil {
+			return false, err
+		}
+		if key == "gte" {
+			return n >= limit, nil
+		}
+		return n <= limit, nil
+	case "min", "max", "len":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return false, err
+		}
+		n, err := measure(fv, key == "len")
+		if err != nil {
+			return false, err
+		}
+		switch key {
+		case "min":
+			return n >= limit, nil
+		case "max":
+			return n <= limit, nil
+		default:
+			return n == limit, nil
+		}
+	case "oneof":
+		s := fmt.Sprint(fv.Interface())
+		for _, candidate := range strings.Fields(param) {
+			if s == candidate {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "email":
+		if fv.Kind() != reflect.String {
+			return false, fmt.Errorf("email rule requires a string field")
+		}
+		return emailRegexp.MatchString(fv.String()), nil
+	case "regexp":
+		if fv.Kind() != reflect.String {
+			return false, fmt.Errorf("regexp rule requires a string field")
+		}
+		re, err := compileRegexp(param)
+		if err != nil {
+			return false, err
+		}
+		return re.MatchString(fv.String()), nil
+	default:
+		return false, fmt.Errorf("unknown rule %s", key)
+	}
+}
+
+// measure 返回用于 min/max/len 比较的量：数字为其值，字符串（按字符计）、切片、map 为长度
+func measure(fv reflect.Value, lengthOnly bool) (float64, error) {
+	switch fv.Kind() {
+	case reflect.String:
+		return float64(len([]rune(fv.String()))), nil
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(fv.Len()), nil
+	}
+	if lengthOnly {
+		return 0, fmt.Errorf("len rule requires a string, slice or map field")
+	}
+	return number(fv)
+}
+
+// number 返回数字字段的值
+func number(fv reflect.Value) (float64, error) {
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(fv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(fv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return fv.Float(), nil
+	}
+	return 0, fmt.Errorf("unsupported type %s", fv.Type())
+}
@@ -1,7 +1,12 @@
 module example
 
-go 1.20
+go 1.21
 
 require gee v0.0.0
 
+require (
+	google.golang.org/protobuf v1.34.2 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
 replace gee => ./gee